@@ -0,0 +1,115 @@
+// Package audit records administrative actions as a structured, queryable
+// trail: who (actor UID/role/IP) did what (action/target) and when, with the
+// request payload sent to the handler, the response it produced and whether
+// the action succeeded. This mirrors how the Harbor project treats repository and tag
+// mutations as first-class audit-worthy events.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+)
+
+// Outcome is the result of an audited action.
+type Outcome string
+
+// Supported outcomes.
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Actor identifies who performed an audited action.
+type Actor struct {
+	UID  int64  `json:"uid,omitempty"`
+	Name string `json:"name,omitempty"`
+	Role string `json:"role,omitempty"`
+	IP   string `json:"ip,omitempty"`
+}
+
+// Event is a single audited administrative action.
+type Event struct {
+	ID        int64           `json:"id"`
+	Actor     Actor           `json:"actor"`
+	Timestamp time.Time       `json:"timestamp"`
+	Action    string          `json:"action"`           // HTTP method and matched route, e.g. "PUT /api/v1/users/{id}"
+	Target    string          `json:"target"`           // request path the action was performed on, e.g. "/api/v1/users/42"
+	Before    json.RawMessage `json:"before,omitempty"` // request payload sent to the handler, not the resource's actual prior state
+	After     json.RawMessage `json:"after,omitempty"`  // response body the handler produced
+	Outcome   Outcome         `json:"outcome"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// Filter narrows a Store.Find query. Zero values are treated as "no constraint".
+type Filter struct {
+	Action string
+	Actor  string
+	Target string
+	From   time.Time
+	To     time.Time
+	Skip   int
+	Limit  int
+}
+
+// Store persists and retrieves audit events. It is expected to be implemented
+// by the engine.Interface backing the running server.
+type Store interface {
+	CreateAuditEvent(ctx context.Context, event Event) (Event, error)
+	FindAuditEvents(ctx context.Context, filter Filter) (events []Event, total int64, err error)
+}
+
+// Logger writes audited actions to a Store. A nil Logger or one built with a
+// nil Store is a no-op, so callers can record unconditionally.
+type Logger struct {
+	store Store
+	l     log.L
+}
+
+// NewLogger builds a Logger persisting events to store. l defaults to log.Default() when nil.
+func NewLogger(store Store, l log.L) *Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return &Logger{store: store, l: l}
+}
+
+// Record persists event, filling in Timestamp if it's unset. Failures to
+// persist are logged rather than propagated, so a broken audit store can
+// never block the action it's recording.
+func (lg *Logger) Record(ctx context.Context, event Event) {
+	if lg == nil || lg.store == nil {
+		return
+	}
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	if _, err := lg.store.CreateAuditEvent(ctx, event); err != nil {
+		lg.l.Logf("[WARN] failed to persist audit event for action %q on %q: %v", event.Action, event.Target, err)
+	}
+}
+
+// List returns events matching filter, delegating to the backing Store.
+func (lg *Logger) List(ctx context.Context, filter Filter) ([]Event, int64, error) {
+	if lg == nil || lg.store == nil {
+		return nil, 0, nil
+	}
+	return lg.store.FindAuditEvents(ctx, filter)
+}
+
+// WriteNDJSON writes events as newline-delimited JSON, the format SIEM
+// ingestion pipelines typically expect.
+func WriteNDJSON(w io.Writer, events []Event) error {
+	enc := json.NewEncoder(w)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
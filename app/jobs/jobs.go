@@ -0,0 +1,202 @@
+// Package jobs implements a minimal background runner for long-running
+// registry maintenance operations - bulk deletes, retention sweeps, and
+// cross-repository copies - that need to report progress and per-item
+// errors back to the API instead of blocking the request that started them.
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+)
+
+// Status is the lifecycle state of a Job.
+type Status string
+
+// Supported statuses.
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// ItemError records a failure for a single item processed by a job.
+type ItemError struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+// Job is the persisted record of a background operation's progress.
+type Job struct {
+	ID        int64       `json:"id"`
+	Type      string      `json:"type"` // e.g. "bulk-delete", "retention", "copy"
+	Status    Status      `json:"status"`
+	Progress  int         `json:"progress"` // items processed so far
+	Total     int         `json:"total"`    // items expected, 0 when unknown upfront
+	Errors    []ItemError `json:"errors,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Store persists job records. Expected to be implemented by the
+// engine.Interface backing the running server; a Runner built without one
+// still tracks jobs in memory for the life of the process.
+type Store interface {
+	CreateJob(ctx context.Context, job Job) (Job, error)
+	UpdateJob(ctx context.Context, job Job) error
+}
+
+// Runner executes jobs in the background and tracks their progress.
+type Runner struct {
+	mu      sync.Mutex
+	store   Store
+	jobs    map[int64]*Job
+	cancel  map[int64]context.CancelFunc
+	created map[int64]bool
+	nextID  int64
+	l       log.L
+}
+
+// NewRunner builds a Runner. store may be nil, in which case jobs are only
+// tracked in memory. l defaults to log.Default() when nil.
+func NewRunner(store Store, l log.L) *Runner {
+	if l == nil {
+		l = log.Default()
+	}
+	return &Runner{
+		store:   store,
+		jobs:    map[int64]*Job{},
+		cancel:  map[int64]context.CancelFunc{},
+		created: map[int64]bool{},
+		l:       l,
+	}
+}
+
+// Report is called by a running job for every item it processes; a non-nil
+// err records the item as failed without stopping the job.
+type Report func(item string, err error)
+
+// Submit starts fn in the background as a new job of the given type and
+// returns the created Job immediately; fn should call report once per item
+// it processes so Progress/Errors stay accurate.
+func (r *Runner) Submit(ctx context.Context, jobType string, total int, fn func(ctx context.Context, report Report) error) Job {
+	r.mu.Lock()
+	r.nextID++
+	job := Job{ID: r.nextID, Type: jobType, Status: StatusPending, Total: total, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	r.jobs[job.ID] = &job
+
+	runCtx, cancel := context.WithCancel(ctx)
+	r.cancel[job.ID] = cancel
+	r.mu.Unlock()
+
+	r.persist(ctx, job)
+
+	go r.run(runCtx, job.ID, fn)
+
+	return job
+}
+
+func (r *Runner) run(ctx context.Context, id int64, fn func(ctx context.Context, report Report) error) {
+	r.setStatus(id, StatusRunning)
+
+	report := func(item string, err error) {
+		r.mu.Lock()
+		job, ok := r.jobs[id]
+		var snapshot Job
+		if ok {
+			job.Progress++
+			if err != nil {
+				job.Errors = append(job.Errors, ItemError{Item: item, Error: err.Error()})
+			}
+			job.UpdatedAt = time.Now()
+			snapshot = *job
+		}
+		r.mu.Unlock()
+
+		if ok {
+			// use a context independent of the job's, so a cancelled job still
+			// persists progress made up to the point it was cancelled
+			r.persist(context.Background(), snapshot)
+		}
+	}
+
+	err := fn(ctx, report)
+
+	status := StatusSucceeded
+	switch {
+	case ctx.Err() != nil:
+		status = StatusCancelled
+	case err != nil:
+		status = StatusFailed
+		r.l.Logf("[WARN] job %d failed: %v", id, err)
+	}
+	r.setStatus(id, status)
+}
+
+func (r *Runner) setStatus(id int64, status Status) {
+	r.mu.Lock()
+	job, ok := r.jobs[id]
+	if ok {
+		job.Status = status
+		job.UpdatedAt = time.Now()
+	}
+	var snapshot Job
+	if ok {
+		snapshot = *job
+	}
+	r.mu.Unlock()
+
+	if ok {
+		r.persist(context.Background(), snapshot)
+	}
+}
+
+func (r *Runner) persist(ctx context.Context, job Job) {
+	if r.store == nil {
+		return
+	}
+
+	r.mu.Lock()
+	firstWrite := !r.created[job.ID]
+	r.created[job.ID] = true
+	r.mu.Unlock()
+
+	var err error
+	if firstWrite {
+		_, err = r.store.CreateJob(ctx, job)
+	} else {
+		err = r.store.UpdateJob(ctx, job)
+	}
+	if err != nil {
+		r.l.Logf("[WARN] failed to persist job %d: %v", job.ID, err)
+	}
+}
+
+// Get returns a snapshot of the job identified by id.
+func (r *Runner) Get(id int64) (Job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Cancel requests that the job identified by id stop as soon as possible.
+// It reports whether a job with that id was found; for a job that has already
+// finished it still returns true and the call has no effect.
+func (r *Runner) Cancel(id int64) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancel[id]
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	cancel()
+	return true
+}
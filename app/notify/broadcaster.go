@@ -0,0 +1,303 @@
+package notify
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	log "github.com/go-pkgz/lgr"
+)
+
+// Endpoint describes where a subset of events should be delivered.
+type Endpoint struct {
+	ID         int64       `json:"id"`
+	URL        string      `json:"url"`
+	Secret     string      `json:"secret"`
+	EventTypes []EventType `json:"event_types,omitempty"` // empty means all event types
+	RepoRegexp string      `json:"repo_regexp,omitempty"` // empty means all repos
+	Roles      []string    `json:"roles,omitempty"`       // empty means any actor role
+	Disabled   bool        `json:"disabled"`
+
+	repoRe *regexp.Regexp
+}
+
+// matches reports whether event should be delivered to this endpoint.
+func (e *Endpoint) matches(event Event) bool {
+	if e.Disabled {
+		return false
+	}
+
+	if len(e.EventTypes) > 0 {
+		found := false
+		for _, t := range e.EventTypes {
+			if t == event.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if e.repoRe != nil && !e.repoRe.MatchString(event.Repo) {
+		return false
+	}
+
+	if len(e.Roles) > 0 {
+		found := false
+		for _, role := range e.Roles {
+			if role == event.Actor.Role {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// defaultQueueSize bounds the number of deliveries buffered across all
+// endpoints before the broadcaster starts dropping newly published events to
+// protect memory.
+const defaultQueueSize = 1000
+
+// deliveryWorkers is the number of goroutines draining the delivery queue. It
+// bounds how many endpoint deliveries are ever in flight at once, independent
+// of how many endpoints are configured or how bursty Publish calls are.
+const deliveryWorkers = 8
+
+// delivery pairs an event with the single endpoint it should be sent to.
+type delivery struct {
+	endpoint *Endpoint
+	event    Event
+}
+
+// Store persists notification endpoints. Expected to be implemented by the
+// engine.Interface backing the running server; a Broadcaster built without
+// one only tracks endpoints in memory for the life of the process, so
+// configured webhooks don't survive a restart.
+type Store interface {
+	CreateNotificationEndpoint(ctx context.Context, e Endpoint) (Endpoint, error)
+	UpdateNotificationEndpoint(ctx context.Context, e Endpoint) error
+	DeleteNotificationEndpoint(ctx context.Context, id int64) error
+	FindNotificationEndpoints(ctx context.Context) ([]Endpoint, error)
+}
+
+// Broadcaster fans incoming events out to every matching Endpoint, delivering
+// through a bounded in-memory queue so a slow or unreachable endpoint can
+// never block the request that published the event. Once the queue is full,
+// Publish drops the event rather than blocking or growing without bound.
+type Broadcaster struct {
+	mu        sync.RWMutex
+	endpoints map[int64]*Endpoint
+	nextID    int64
+	queueSize int
+	queue     chan delivery
+	store     Store
+	l         log.L
+
+	newSink func(e *Endpoint) Sink
+}
+
+// NewBroadcaster creates a Broadcaster, restoring any endpoints persisted in
+// store, and starts its delivery worker pool. store may be nil, in which case
+// endpoints are only tracked in memory. l defaults to log.Default() when nil.
+func NewBroadcaster(queueSize int, store Store, l log.L) *Broadcaster {
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	if l == nil {
+		l = log.Default()
+	}
+	b := &Broadcaster{
+		endpoints: map[int64]*Endpoint{},
+		queueSize: queueSize,
+		queue:     make(chan delivery, queueSize),
+		store:     store,
+		l:         l,
+		newSink: func(e *Endpoint) Sink {
+			return &HTTPSink{URL: e.URL, Secret: e.Secret}
+		},
+	}
+	b.loadEndpoints()
+	for i := 0; i < deliveryWorkers; i++ {
+		go b.runWorker()
+	}
+	return b
+}
+
+// loadEndpoints restores previously persisted endpoints from store, if any,
+// so configured webhooks survive a process restart.
+func (b *Broadcaster) loadEndpoints() {
+	if b.store == nil {
+		return
+	}
+
+	endpoints, err := b.store.FindNotificationEndpoints(context.Background())
+	if err != nil {
+		b.l.Logf("[WARN] failed to load persisted notification endpoints: %v", err)
+		return
+	}
+
+	for _, e := range endpoints {
+		e := e // each map entry needs its own copy, not the shared range variable
+		if e.RepoRegexp != "" {
+			if re, reErr := regexp.Compile(e.RepoRegexp); reErr == nil {
+				e.repoRe = re
+			}
+		}
+		b.endpoints[e.ID] = &e
+		if e.ID > b.nextID {
+			b.nextID = e.ID
+		}
+	}
+}
+
+// runWorker drains the delivery queue for the life of the process; the queue
+// is never closed and there's no Close/Shutdown, matching how the rest of this
+// server's background workers (e.g. jobs.Runner) run for the process lifetime.
+func (b *Broadcaster) runWorker() {
+	for d := range b.queue {
+		if err := b.newSink(d.endpoint).Send(context.Background(), d.event); err != nil {
+			log.Printf("[WARN] failed to deliver %s notification to endpoint %d: %v", d.event.Type, d.endpoint.ID, err)
+		}
+	}
+}
+
+// Publish fans event out to every matching endpoint by enqueueing one
+// delivery per endpoint onto the bounded queue; Publish itself never blocks
+// on network I/O. When the queue is already full, the event is dropped for
+// the endpoints it didn't fit for and a warning is logged.
+func (b *Broadcaster) Publish(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, e := range b.endpoints {
+		if !e.matches(event) {
+			continue
+		}
+		select {
+		case b.queue <- delivery{endpoint: e, event: event}:
+		default:
+			log.Printf("[WARN] notification queue full, dropping %s event for endpoint %d", event.Type, e.ID)
+		}
+	}
+}
+
+// AddEndpoint registers a new notification endpoint and returns its assigned ID.
+func (b *Broadcaster) AddEndpoint(e Endpoint) (Endpoint, error) {
+	if e.URL == "" {
+		return Endpoint{}, errors.New("endpoint url is required")
+	}
+
+	if e.RepoRegexp != "" {
+		re, err := regexp.Compile(e.RepoRegexp)
+		if err != nil {
+			return Endpoint{}, errors.Wrap(err, "invalid repo_regexp")
+		}
+		e.repoRe = re
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	e.ID = b.nextID
+	b.endpoints[e.ID] = &e
+	b.mu.Unlock()
+
+	b.persistCreate(e)
+
+	return e, nil
+}
+
+// UpdateEndpoint replaces the endpoint identified by e.ID.
+func (b *Broadcaster) UpdateEndpoint(e Endpoint) (Endpoint, error) {
+	if e.RepoRegexp != "" {
+		re, err := regexp.Compile(e.RepoRegexp)
+		if err != nil {
+			return Endpoint{}, errors.Wrap(err, "invalid repo_regexp")
+		}
+		e.repoRe = re
+	}
+
+	b.mu.Lock()
+	if _, ok := b.endpoints[e.ID]; !ok {
+		b.mu.Unlock()
+		return Endpoint{}, errors.Errorf("endpoint %d not found", e.ID)
+	}
+	b.endpoints[e.ID] = &e
+	b.mu.Unlock()
+
+	b.persistUpdate(e)
+
+	return e, nil
+}
+
+// RemoveEndpoint deletes the endpoint identified by id.
+func (b *Broadcaster) RemoveEndpoint(id int64) error {
+	b.mu.Lock()
+	if _, ok := b.endpoints[id]; !ok {
+		b.mu.Unlock()
+		return errors.Errorf("endpoint %d not found", id)
+	}
+	delete(b.endpoints, id)
+	b.mu.Unlock()
+
+	b.persistDelete(id)
+
+	return nil
+}
+
+// persistCreate, persistUpdate and persistDelete keep store in sync with the
+// in-memory endpoint map. Failures are logged rather than propagated, same as
+// audit.Logger.Record and jobs.Runner.persist: a broken endpoint store only
+// means the next restart won't recover endpoints added since, it never blocks
+// the CRUD call itself.
+func (b *Broadcaster) persistCreate(e Endpoint) {
+	if b.store == nil {
+		return
+	}
+	if _, err := b.store.CreateNotificationEndpoint(context.Background(), e); err != nil {
+		b.l.Logf("[WARN] failed to persist notification endpoint %d: %v", e.ID, err)
+	}
+}
+
+func (b *Broadcaster) persistUpdate(e Endpoint) {
+	if b.store == nil {
+		return
+	}
+	if err := b.store.UpdateNotificationEndpoint(context.Background(), e); err != nil {
+		b.l.Logf("[WARN] failed to persist notification endpoint %d: %v", e.ID, err)
+	}
+}
+
+func (b *Broadcaster) persistDelete(id int64) {
+	if b.store == nil {
+		return
+	}
+	if err := b.store.DeleteNotificationEndpoint(context.Background(), id); err != nil {
+		b.l.Logf("[WARN] failed to delete persisted notification endpoint %d: %v", id, err)
+	}
+}
+
+// ListEndpoints returns all configured endpoints.
+func (b *Broadcaster) ListEndpoints() []Endpoint {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	result := make([]Endpoint, 0, len(b.endpoints))
+	for _, e := range b.endpoints {
+		result = append(result, *e)
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEndpoint_matches(t *testing.T) {
+	b := NewBroadcaster(1, nil, nil)
+	repoScoped, err := b.AddEndpoint(Endpoint{URL: "http://repo.example", RepoRegexp: "^team/"})
+	if err != nil {
+		t.Fatalf("AddEndpoint: %v", err)
+	}
+
+	tbl := []struct {
+		name     string
+		endpoint Endpoint
+		event    Event
+		want     bool
+	}{
+		{
+			name:     "disabled endpoint never matches",
+			endpoint: Endpoint{Disabled: true},
+			event:    Event{Type: EventRegistryPush},
+			want:     false,
+		},
+		{
+			name:     "empty filters match everything",
+			endpoint: Endpoint{},
+			event:    Event{Type: EventRegistryPush, Repo: "team/api", Actor: Actor{Role: "admin"}},
+			want:     true,
+		},
+		{
+			name:     "event type filter excludes non-matching types",
+			endpoint: Endpoint{EventTypes: []EventType{EventRegistryDelete}},
+			event:    Event{Type: EventRegistryPush},
+			want:     false,
+		},
+		{
+			name:     "event type filter allows matching types",
+			endpoint: Endpoint{EventTypes: []EventType{EventRegistryPush, EventRegistryDelete}},
+			event:    Event{Type: EventRegistryDelete},
+			want:     true,
+		},
+		{
+			name:     "repo regexp excludes non-matching repos",
+			endpoint: repoScoped,
+			event:    Event{Type: EventRegistryPush, Repo: "other/api"},
+			want:     false,
+		},
+		{
+			name:     "repo regexp allows matching repos",
+			endpoint: repoScoped,
+			event:    Event{Type: EventRegistryPush, Repo: "team/api"},
+			want:     true,
+		},
+		{
+			name:     "role filter excludes non-matching actor roles",
+			endpoint: Endpoint{Roles: []string{"admin"}},
+			event:    Event{Type: EventRegistryPush, Actor: Actor{Role: "viewer"}},
+			want:     false,
+		},
+		{
+			name:     "role filter allows matching actor roles",
+			endpoint: Endpoint{Roles: []string{"admin", "manager"}},
+			event:    Event{Type: EventRegistryPush, Actor: Actor{Role: "manager"}},
+			want:     true,
+		},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := tt.endpoint
+			if got := endpoint.matches(tt.event); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type sinkFunc func(ctx context.Context, event Event) error
+
+func (f sinkFunc) Send(ctx context.Context, event Event) error { return f(ctx, event) }
+
+// TestBroadcaster_Publish verifies Publish only delivers to matching, enabled
+// endpoints, stamps a Timestamp when the caller didn't set one, and drops
+// deliveries once the bounded queue is full instead of blocking or spawning
+// unbounded goroutines.
+func TestBroadcaster_Publish(t *testing.T) {
+	b := NewBroadcaster(1, nil, nil)
+
+	var delivered int32
+	var gotType EventType
+	var gotTimestamp time.Time
+	gotURLs := map[string]bool{}
+	var mu sync.Mutex
+	release := make(chan struct{})
+
+	b.newSink = func(e *Endpoint) Sink {
+		return sinkFunc(func(_ context.Context, event Event) error {
+			<-release // hold every delivery open so the queue backs up
+			mu.Lock()
+			gotType = event.Type
+			gotTimestamp = event.Timestamp
+			gotURLs[e.URL] = true
+			mu.Unlock()
+			atomic.AddInt32(&delivered, 1)
+			return nil
+		})
+	}
+
+	if _, err := b.AddEndpoint(Endpoint{URL: "http://push.example", EventTypes: []EventType{EventRegistryPush}}); err != nil {
+		t.Fatalf("AddEndpoint: %v", err)
+	}
+	if _, err := b.AddEndpoint(Endpoint{URL: "http://delete.example", EventTypes: []EventType{EventRegistryDelete}}); err != nil {
+		t.Fatalf("AddEndpoint: %v", err)
+	}
+	if _, err := b.AddEndpoint(Endpoint{URL: "http://disabled.example", Disabled: true}); err != nil {
+		t.Fatalf("AddEndpoint: %v", err)
+	}
+
+	// queueSize(1) + deliveryWorkers in-flight slots is nowhere near enough
+	// to absorb this burst while every delivery is blocked on release, so
+	// Publish must drop the excess rather than block or leak goroutines.
+	for i := 0; i < 50; i++ {
+		b.Publish(Event{Type: EventRegistryPush})
+	}
+
+	close(release)
+
+	deadline := time.After(2 * time.Second)
+	for atomic.LoadInt32(&delivered) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for at least one delivery")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if gotType != EventRegistryPush {
+		t.Errorf("delivered event type = %q, want %q (delete/disabled endpoints should never fire)", gotType, EventRegistryPush)
+	}
+	for url := range gotURLs {
+		if url != "http://push.example" {
+			t.Errorf("delivered to endpoint %q, want only %q", url, "http://push.example")
+		}
+	}
+	if gotTimestamp.IsZero() {
+		t.Error("Publish should stamp a zero-value Timestamp before delivery")
+	}
+}
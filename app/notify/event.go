@@ -0,0 +1,40 @@
+// Package notify implements webhook fan-out for registry lifecycle events,
+// modeled on the Docker distribution notifications package: mutating actions
+// are published as signed JSON payloads to a set of configured HTTP endpoints.
+package notify
+
+import "time"
+
+// EventType identifies the kind of action a notification was raised for.
+type EventType string
+
+// Supported event types. Registry-originated events mirror the action names
+// reported by the Docker Registry; admin-initiated events are named after the
+// handler that triggered them.
+const (
+	EventRegistryPush       EventType = "registry.push"
+	EventRegistryPull       EventType = "registry.pull"
+	EventRegistryDelete     EventType = "registry.delete"
+	EventRepositoriesSynced EventType = "repositories.synced"
+	EventUserCreated        EventType = "user.created"
+	EventUserUpdated        EventType = "user.updated"
+	EventUserDeleted        EventType = "user.deleted"
+	EventHtpasswdUpdated    EventType = "htpasswd.updated"
+)
+
+// Actor identifies who (or what) triggered an event.
+type Actor struct {
+	UID  int64  `json:"uid,omitempty"`
+	Name string `json:"name,omitempty"`
+	Role string `json:"role,omitempty"`
+}
+
+// Event is the payload fanned out to every matching endpoint. Repo is left
+// empty for events not scoped to a single repository (e.g. user management).
+type Event struct {
+	Type      EventType   `json:"type"`
+	Repo      string      `json:"repo,omitempty"`
+	Actor     Actor       `json:"actor"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
+}
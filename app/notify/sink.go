@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Sink delivers a single event somewhere. The broadcaster calls Send once per
+// delivery and only logs a returned error; any retry/backoff is up to the Sink
+// itself (HTTPSink retries transient failures).
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// HTTPSink delivers events as a signed JSON POST to a single endpoint URL,
+// retrying transient failures with exponential backoff.
+type HTTPSink struct {
+	URL        string
+	Secret     string // used to sign the payload with HMAC-SHA256, header X-Registry-Signature
+	Client     *http.Client
+	MaxRetries int           // default 3 when zero
+	BaseDelay  time.Duration // default 500ms when zero
+}
+
+// Send posts the event to sink.URL, retrying on network errors or 5xx responses.
+func (s *HTTPSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal event")
+	}
+
+	maxRetries := s.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 3
+	}
+	baseDelay := s.BaseDelay
+	if baseDelay <= 0 {
+		baseDelay = 500 * time.Millisecond
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(baseDelay * time.Duration(1<<uint(attempt-1))):
+			}
+		}
+
+		req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+		if errReq != nil {
+			return errors.Wrap(errReq, "failed to build notification request")
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Registry-Signature", sign(s.Secret, body))
+
+		resp, errDo := client.Do(req)
+		if errDo != nil {
+			lastErr = errDo
+			continue
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("endpoint %s responded with status %d", s.URL, resp.StatusCode)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("endpoint %s rejected event: status %d", s.URL, resp.StatusCode)
+		}
+
+		return nil
+	}
+
+	return errors.Wrapf(lastErr, "giving up delivering event to %s after %d attempts", s.URL, maxRetries+1)
+}
+
+// sign computes the hex-encoded HMAC-SHA256 of body using secret as key.
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
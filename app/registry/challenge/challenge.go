@@ -0,0 +1,189 @@
+// Package challenge parses WWW-Authenticate challenges per RFC 7235 and keeps
+// track of the last challenges seen for a given registry endpoint, so a
+// caller can pick the right AuthHandler (Bearer, Basic, ...) for a request
+// without re-parsing the header on every call.
+package challenge
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Challenge is a single parsed "Scheme param=value, param=value" entry from a
+// WWW-Authenticate header. Scheme is normalized to its canonical casing
+// (e.g. "Bearer", "Basic"); parameter names are lower-cased, values are not.
+type Challenge struct {
+	Scheme     string
+	Parameters map[string]string
+}
+
+// Parse splits a WWW-Authenticate header value into its individual
+// challenges. The header may contain multiple comma-separated challenges,
+// each with its own scheme and quoted or unquoted parameter values, e.g.:
+//
+//	Bearer realm="https://auth.example.com/token",service="registry.example.com"
+//	Basic realm="Registry Realm", Bearer realm="https://auth.example.com/token"
+func Parse(header string) []Challenge {
+	var challenges []Challenge
+
+	for _, part := range splitChallenges(header) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		scheme, rest := splitScheme(part)
+		if scheme == "" {
+			continue
+		}
+
+		challenges = append(challenges, Challenge{
+			Scheme:     canonicalScheme(scheme),
+			Parameters: parseParameters(rest),
+		})
+	}
+
+	return challenges
+}
+
+// splitScheme extracts the leading auth-scheme token (e.g. "Bearer") from a
+// single challenge and returns the remaining parameter list.
+func splitScheme(challenge string) (scheme, rest string) {
+	idx := strings.IndexByte(challenge, ' ')
+	if idx < 0 {
+		return challenge, ""
+	}
+	return challenge[:idx], challenge[idx+1:]
+}
+
+// canonicalScheme normalizes an auth-scheme token to the casing AuthHandler
+// implementations and Manager.Authorize expect (e.g. "Bearer", "Basic").
+// RFC 7235 auth-scheme tokens are case-insensitive, so registries that send
+// "bearer" or "BASIC" must still match a handler registered under the
+// canonical name.
+func canonicalScheme(scheme string) string {
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		return "Bearer"
+	case "basic":
+		return "Basic"
+	default:
+		if scheme == "" {
+			return scheme
+		}
+		return strings.ToUpper(scheme[:1]) + strings.ToLower(scheme[1:])
+	}
+}
+
+// splitChallenges splits a full header value into individual challenges,
+// being careful not to split on commas that appear inside quoted parameter
+// values. A new challenge starts wherever a bare "Scheme " token follows a
+// comma at the top level.
+func splitChallenges(header string) []string {
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+
+	for i := 0; i < len(header); i++ {
+		c := header[i]
+		switch c {
+		case '"':
+			inQuotes = !inQuotes
+			current.WriteByte(c)
+		case ',':
+			if inQuotes {
+				current.WriteByte(c)
+				continue
+			}
+			// a comma starts a new challenge only when followed by "token=" is false,
+			// i.e. when the next non-space run looks like "Scheme " rather than "param=".
+			rest := strings.TrimLeft(header[i+1:], " ")
+			if looksLikeNewChallenge(rest) {
+				parts = append(parts, current.String())
+				current.Reset()
+				continue
+			}
+			current.WriteByte(c)
+		default:
+			current.WriteByte(c)
+		}
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+
+	return parts
+}
+
+// looksLikeNewChallenge reports whether s starts with a bare scheme token
+// (no "=") followed by a space, which signals the start of a new challenge
+// rather than another parameter of the current one.
+func looksLikeNewChallenge(s string) bool {
+	spaceIdx := strings.IndexByte(s, ' ')
+	eqIdx := strings.IndexByte(s, '=')
+	if spaceIdx < 0 {
+		return false
+	}
+	return eqIdx < 0 || spaceIdx < eqIdx
+}
+
+// parseParameters parses a comma-separated "name=value" or `name="value"` list.
+func parseParameters(s string) map[string]string {
+	params := map[string]string{}
+
+	for _, raw := range splitTopLevelCommas(s) {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		eqIdx := strings.IndexByte(raw, '=')
+		if eqIdx < 0 {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(raw[:eqIdx]))
+		value := strings.TrimSpace(raw[eqIdx+1:])
+		value = strings.Trim(value, `"`)
+		params[name] = value
+	}
+
+	return params
+}
+
+// splitTopLevelCommas splits on commas that are not inside a quoted value.
+func splitTopLevelCommas(s string) []string {
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			inQuotes = !inQuotes
+			current.WriteByte(c)
+		case ',':
+			if inQuotes {
+				current.WriteByte(c)
+				continue
+			}
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+
+	return parts
+}
+
+// ResponseChallenges parses all WWW-Authenticate headers on an HTTP response.
+func ResponseChallenges(resp *http.Response) []Challenge {
+	var challenges []Challenge
+	for _, header := range resp.Header.Values("WWW-Authenticate") {
+		challenges = append(challenges, Parse(header)...)
+	}
+	return challenges
+}
@@ -0,0 +1,101 @@
+package challenge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tbl := []struct {
+		name   string
+		header string
+		want   []Challenge
+	}{
+		{
+			name:   "single bearer challenge",
+			header: `Bearer realm="https://auth.example.com/token",service="registry.example.com"`,
+			want: []Challenge{
+				{Scheme: "Bearer", Parameters: map[string]string{
+					"realm":   "https://auth.example.com/token",
+					"service": "registry.example.com",
+				}},
+			},
+		},
+		{
+			name:   "multiple challenges separated by a comma",
+			header: `Basic realm="Registry Realm", Bearer realm="https://auth.example.com/token"`,
+			want: []Challenge{
+				{Scheme: "Basic", Parameters: map[string]string{"realm": "Registry Realm"}},
+				{Scheme: "Bearer", Parameters: map[string]string{"realm": "https://auth.example.com/token"}},
+			},
+		},
+		{
+			name:   "lower-case scheme is normalized to canonical casing",
+			header: `bearer realm="https://auth.example.com/token"`,
+			want: []Challenge{
+				{Scheme: "Bearer", Parameters: map[string]string{"realm": "https://auth.example.com/token"}},
+			},
+		},
+		{
+			name:   "upper-case scheme is normalized to canonical casing",
+			header: `BASIC realm="Registry Realm"`,
+			want: []Challenge{
+				{Scheme: "Basic", Parameters: map[string]string{"realm": "Registry Realm"}},
+			},
+		},
+		{
+			name:   "quoted comma in a parameter value doesn't split the challenge",
+			header: `Bearer realm="https://auth.example.com/token",scope="repository:foo,bar:pull"`,
+			want: []Challenge{
+				{Scheme: "Bearer", Parameters: map[string]string{
+					"realm": "https://auth.example.com/token",
+					"scope": "repository:foo,bar:pull",
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.header)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestManager_Authorize_caseInsensitiveScheme verifies that a registry
+// responding with a lower-cased scheme (e.g. "bearer") still matches a
+// handler registered under the canonical "Bearer" name.
+func TestManager_Authorize_caseInsensitiveScheme(t *testing.T) {
+	m := NewManager()
+
+	rec := httptest.NewRecorder()
+	rec.Header().Add("WWW-Authenticate", `bearer realm="https://auth.example.com/token"`)
+	m.AddResponse("https://registry.example.com", rec.Result())
+
+	var gotFetch bool
+	handler := &BearerHandler{Fetch: func(_ context.Context, _ Challenge) (string, error) {
+		gotFetch = true
+		return "tok", nil
+	}}
+
+	req, err := http.NewRequest(http.MethodGet, "https://registry.example.com/v2/_catalog", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	if err = m.Authorize(context.Background(), "https://registry.example.com", req, handler); err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if !gotFetch {
+		t.Error("expected BearerHandler.AuthorizeRequest to run for a lower-cased 'bearer' challenge")
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer tok" {
+		t.Errorf("Authorization header = %q, want %q", got, "Bearer tok")
+	}
+}
@@ -0,0 +1,52 @@
+package challenge
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Manager caches the last challenges seen per registry endpoint host, so
+// repeated requests to the same upstream don't need to re-probe for the
+// WWW-Authenticate header. It is safe for concurrent use.
+type Manager struct {
+	mu         sync.RWMutex
+	challenges map[string][]Challenge
+}
+
+// NewManager creates an empty Manager.
+func NewManager() *Manager {
+	return &Manager{challenges: map[string][]Challenge{}}
+}
+
+// AddResponse records the challenges carried by resp against the endpoint it
+// came from. Responses without a WWW-Authenticate header are ignored.
+func (m *Manager) AddResponse(endpoint string, resp *http.Response) {
+	challenges := ResponseChallenges(resp)
+	if len(challenges) == 0 {
+		return
+	}
+
+	key := normalizeEndpoint(endpoint)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.challenges[key] = challenges
+}
+
+// GetChallenges returns the last challenges seen for endpoint, or nil if none are known.
+func (m *Manager) GetChallenges(endpoint string) []Challenge {
+	key := normalizeEndpoint(endpoint)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.challenges[key]
+}
+
+// normalizeEndpoint keys the cache by scheme+host so paths/queries on the
+// same registry don't fragment the cached challenges.
+func normalizeEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return u.Scheme + "://" + u.Host
+}
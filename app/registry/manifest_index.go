@@ -0,0 +1,35 @@
+package registry
+
+// Supported multi-arch manifest media types: an OCI image index or the
+// equivalent (older) Docker manifest list, both of which fan out to one
+// manifest per platform rather than a single image config.
+const (
+	MediaTypeOCIImageIndex      = "application/vnd.oci.image.index.v1+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// IsManifestIndex reports whether mediaType identifies a multi-arch manifest
+// index/list rather than a single-platform image manifest.
+func IsManifestIndex(mediaType string) bool {
+	return mediaType == MediaTypeOCIImageIndex || mediaType == MediaTypeDockerManifestList
+}
+
+// Platform identifies the OS/architecture/variant a manifest within an index targets.
+type Platform struct {
+	OS      string `json:"os"`
+	Arch    string `json:"architecture"`
+	Variant string `json:"variant,omitempty"`
+}
+
+// ManifestIndexEntry is a single platform-specific manifest referenced by an index.
+type ManifestIndexEntry struct {
+	Digest    string   `json:"digest"`
+	MediaType string   `json:"mediaType"`
+	Platform  Platform `json:"platform"`
+}
+
+// ManifestIndex is a parsed OCI image index / Docker manifest list.
+type ManifestIndex struct {
+	MediaType string               `json:"mediaType"`
+	Manifests []ManifestIndexEntry `json:"manifests"`
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	R "github.com/go-pkgz/rest"
+
+	"github.com/zebox/registry-admin/app/audit"
+)
+
+// auditHandlers exposes the audit trail for administrators.
+type auditHandlers struct {
+	endpointsHandler
+	logger *audit.Logger
+}
+
+// auditListCtrl returns audit events matching the request's query filters.
+// Pagination/filter query params follow the same react-admin conventions the
+// rest of the API uses: "_start"/"_end" for the page window, "X-Total-Count"
+// reported back in the response header.
+func (ah *auditHandlers) auditListCtrl(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		R.SendErrorJSON(w, r, ah.l, http.StatusBadRequest, err, "failed to parse audit query")
+		return
+	}
+
+	events, total, err := ah.logger.List(r.Context(), filter)
+	if err != nil {
+		R.SendErrorJSON(w, r, ah.l, http.StatusInternalServerError, err, "failed to load audit events")
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	R.JSON(w, r, events)
+}
+
+// auditExportCtrl streams every audit event matching the query filters as
+// newline-delimited JSON, for ingestion by an external SIEM.
+func (ah *auditHandlers) auditExportCtrl(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		R.SendErrorJSON(w, r, ah.l, http.StatusBadRequest, err, "failed to parse audit query")
+		return
+	}
+	filter.Skip, filter.Limit = 0, 0
+
+	events, _, err := ah.logger.List(r.Context(), filter)
+	if err != nil {
+		R.SendErrorJSON(w, r, ah.l, http.StatusInternalServerError, err, "failed to load audit events")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-log.ndjson"`)
+	if err = audit.WriteNDJSON(w, events); err != nil {
+		ah.l.Logf("[WARN] failed to stream audit export: %v", err)
+	}
+}
+
+// parseAuditFilter builds an audit.Filter from query parameters.
+func parseAuditFilter(r *http.Request) (audit.Filter, error) {
+	q := r.URL.Query()
+
+	filter := audit.Filter{
+		Action: q.Get("action"),
+		Actor:  q.Get("actor"),
+		Target: q.Get("target"),
+	}
+
+	var err error
+	if from := q.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return filter, err
+		}
+	}
+	if to := q.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return filter, err
+		}
+	}
+
+	start, end := q.Get("_start"), q.Get("_end")
+	if start != "" && end != "" {
+		startN, errStart := strconv.Atoi(start)
+		endN, errEnd := strconv.Atoi(end)
+		if errStart != nil {
+			return filter, errStart
+		}
+		if errEnd != nil {
+			return filter, errEnd
+		}
+		filter.Skip = startN
+		filter.Limit = endN - startN
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-pkgz/auth/token"
+
+	"github.com/zebox/registry-admin/app/audit"
+)
+
+// auditMiddleware wraps mutating routes and records one audit.Event per
+// request: actor from the authenticated token, IP from the remote address,
+// action from the method and matched route, target from the request path,
+// before-state from the request payload and after-state from the response
+// the handler produced. It never blocks or fails the
+// request it's recording - a broken audit store only results in a logged
+// warning (see audit.Logger.Record).
+//
+// GET and HEAD requests are skipped: they never mutate anything, so auditing
+// them would just fill the trail with read traffic (e.g. a job status poll)
+// instead of the administrative actions it exists to record.
+func auditMiddleware(logger *audit.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var before []byte
+			if r.Body != nil {
+				before, _ = io.ReadAll(r.Body) //nolint:errcheck // a body read failure just leaves Before empty
+				r.Body.Close()                 //nolint:errcheck
+				r.Body = io.NopCloser(bytes.NewReader(before))
+			}
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			event := audit.Event{
+				Actor:  auditActor(r),
+				Action: r.Method + " " + routePattern(r),
+				Target: r.URL.Path,
+				Before: redactSensitiveFields(before),
+				After:  redactSensitiveFields(rec.body.Bytes()),
+			}
+
+			if rec.status >= 200 && rec.status < 400 {
+				event.Outcome = audit.OutcomeSuccess
+			} else {
+				event.Outcome = audit.OutcomeFailure
+				event.Error = http.StatusText(rec.status)
+			}
+
+			logger.Record(r.Context(), event)
+		})
+	}
+}
+
+// redactedFieldNames are JSON object keys (case-insensitive) whose values are
+// masked out of audit Before/After bodies before they're persisted - e.g. the
+// webhook HMAC secret on /notifications/endpoints and the plaintext password
+// on user create/update, neither of which should sit unredacted in the audit
+// trail or the NDJSON export it feeds to SIEM ingestion.
+var redactedFieldNames = map[string]bool{
+	"password": true,
+	"secret":   true,
+}
+
+// redactSensitiveFields masks redactedFieldNames out of a JSON request/response
+// body. body is returned unchanged when it isn't valid JSON (e.g. empty, or a
+// non-JSON payload), since there's nothing structured to redact.
+func redactSensitiveFields(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return body
+	}
+
+	redactValue(data)
+
+	redacted, err := json.Marshal(data)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactValue walks a decoded JSON value in place, masking any object field
+// whose name matches redactedFieldNames.
+func redactValue(v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if redactedFieldNames[strings.ToLower(k)] {
+				val[k] = "[REDACTED]"
+				continue
+			}
+			redactValue(child)
+		}
+	case []interface{}:
+		for _, item := range val {
+			redactValue(item)
+		}
+	}
+}
+
+// routePattern returns the chi route pattern the request matched, e.g.
+// "/api/v1/users/{id}", falling back to the raw path when unavailable.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return r.URL.Path
+}
+
+// auditActor builds an audit.Actor from the authenticated token claims
+// attached to the request and the caller's remote address.
+func auditActor(r *http.Request) audit.Actor {
+	actor := audit.Actor{IP: r.RemoteAddr}
+
+	user, err := token.GetUserInfo(r)
+	if err != nil {
+		return actor
+	}
+
+	actor.Name = user.Name
+	actor.Role = user.Role()
+	if uid, ok := user.Attributes["uid"].(int64); ok {
+		actor.UID = uid
+	}
+	return actor
+}
+
+// responseRecorder captures the status code and body a handler wrote so the
+// audit middleware can report them after the fact.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRedactSensitiveFields(t *testing.T) {
+	tbl := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "empty body is returned unchanged",
+			body: "",
+			want: "",
+		},
+		{
+			name: "non-JSON body is returned unchanged",
+			body: "not json",
+			want: "not json",
+		},
+		{
+			name: "password and secret fields are masked",
+			body: `{"name":"bob","password":"hunter2","secret":"whsec_abc"}`,
+			want: `{"name":"bob","password":"[REDACTED]","secret":"[REDACTED]"}`,
+		},
+		{
+			name: "field name matching is case-insensitive",
+			body: `{"Password":"hunter2"}`,
+			want: `{"Password":"[REDACTED]"}`,
+		},
+		{
+			name: "nested objects and arrays are redacted too",
+			body: `{"endpoint":{"url":"http://example.com","secret":"whsec_abc"},"items":[{"password":"p1"},{"password":"p2"}]}`,
+			want: `{"endpoint":{"secret":"[REDACTED]","url":"http://example.com"},"items":[{"password":"[REDACTED]"},{"password":"[REDACTED]"}]}`,
+		},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactSensitiveFields([]byte(tt.body))
+			if tt.want == "" || tt.want == "not json" {
+				if string(got) != tt.want {
+					t.Errorf("redactSensitiveFields(%q) = %q, want %q", tt.body, got, tt.want)
+				}
+				return
+			}
+
+			var gotVal, wantVal interface{}
+			if err := json.Unmarshal(got, &gotVal); err != nil {
+				t.Fatalf("redactSensitiveFields returned invalid JSON: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantVal); err != nil {
+				t.Fatalf("invalid want JSON: %v", err)
+			}
+			gotJSON, _ := json.Marshal(gotVal)
+			wantJSON, _ := json.Marshal(wantVal)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("redactSensitiveFields(%q) = %s, want %s", tt.body, gotJSON, wantJSON)
+			}
+		})
+	}
+}
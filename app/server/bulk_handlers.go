@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	R "github.com/go-pkgz/rest"
+	"github.com/pkg/errors"
+
+	"github.com/zebox/registry-admin/app/jobs"
+	"github.com/zebox/registry-admin/app/notify"
+)
+
+// errJobNotFound is returned by jobStatusCtrl when the requested job id is unknown.
+var errJobNotFound = errors.New("job not found")
+
+// bulkHandlers exposes batch registry maintenance endpoints - bulk delete,
+// retention sweeps and cross-repository copies - as background jobs so large
+// operations don't tie up the request that started them.
+type bulkHandlers struct {
+	endpointsHandler
+	registryService registryInterface
+	jobs            *jobs.Runner
+}
+
+// bulkDeleteRequest names the digests to remove from each repository.
+type bulkDeleteRequest struct {
+	Repos []struct {
+		Name    string   `json:"name"`
+		Digests []string `json:"digests"`
+	} `json:"repos"`
+}
+
+// bulkDeleteCtrl deletes a set of digests across one or more repositories as a background job.
+func (bh *bulkHandlers) bulkDeleteCtrl(w http.ResponseWriter, r *http.Request) {
+	var req bulkDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		R.SendErrorJSON(w, r, bh.l, http.StatusBadRequest, err, "failed to parse bulk delete request")
+		return
+	}
+
+	total := 0
+	for _, repo := range req.Repos {
+		total += len(repo.Digests)
+	}
+
+	actor := notifyActor(r)
+	job := bh.jobs.Submit(bh.ctx, "bulk-delete", total, func(ctx context.Context, report jobs.Report) error {
+		for _, repo := range req.Repos {
+			for _, digest := range repo.Digests {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				err := bh.registryService.DeleteTag(ctx, repo.Name, digest)
+				report(repo.Name+"@"+digest, err)
+				if err == nil {
+					bh.publish(notify.Event{Type: notify.EventRegistryDelete, Repo: repo.Name, Actor: actor, Data: digest})
+				}
+			}
+		}
+		return nil
+	})
+
+	R.JSON(w, r, responseMessage{Message: "bulk delete job started", ID: job.ID, Data: job})
+}
+
+// retentionRule prunes tags within matched repositories down to what should be kept.
+type retentionRule struct {
+	Repos         []string      `json:"repos"`           // glob patterns matched against repository names, empty matches all
+	Exclude       []string      `json:"exclude"`         // glob patterns for tags that are never pruned
+	KeepLastN     int           `json:"keep_last_n"`     // always keep the N most recent tags, 0 disables the rule
+	KeepNewerThan time.Duration `json:"keep_newer_than"` // judged by the image config's "created" timestamp; images without one are pruned
+}
+
+// retentionCtrl applies a retention policy across matching repositories as a background job.
+func (bh *bulkHandlers) retentionCtrl(w http.ResponseWriter, r *http.Request) {
+	var rule retentionRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		R.SendErrorJSON(w, r, bh.l, http.StatusBadRequest, err, "failed to parse retention rule")
+		return
+	}
+
+	catalog, err := bh.registryService.Catalog(r.Context(), "", "")
+	if err != nil {
+		R.SendErrorJSON(w, r, bh.l, http.StatusInternalServerError, err, "failed to load catalog for retention sweep")
+		return
+	}
+
+	var repos []string
+	for _, name := range catalog.Repositories {
+		if repoMatches(name, rule.Repos) {
+			repos = append(repos, name)
+		}
+	}
+
+	actor := notifyActor(r)
+	job := bh.jobs.Submit(bh.ctx, "retention", len(repos), func(ctx context.Context, report jobs.Report) error {
+		for _, repoName := range repos {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			onDelete := func(digest string) {
+				bh.publish(notify.Event{Type: notify.EventRegistryDelete, Repo: repoName, Actor: actor, Data: digest})
+			}
+			report(repoName, applyRetention(ctx, bh.registryService, repoName, rule, onDelete))
+		}
+		return nil
+	})
+
+	R.JSON(w, r, responseMessage{Message: "retention job started", ID: job.ID, Data: job})
+}
+
+// applyRetention prunes repoName's tags down to rule.KeepLastN, skipping any tag matching
+// rule.Exclude or younger than rule.KeepNewerThan. Tags are assumed to be returned newest-first
+// by ListingImageTags, the same ordering catalogList relies on. onDelete, if non-nil, is called
+// with the digest of every manifest actually removed.
+func applyRetention(ctx context.Context, rs registryInterface, repoName string, rule retentionRule, onDelete func(digest string)) error {
+	if rule.KeepLastN <= 0 && rule.KeepNewerThan <= 0 {
+		return nil
+	}
+
+	tags, err := rs.ListingImageTags(ctx, repoName, "", "")
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	for _, tag := range tags.Tags {
+		if repoMatches(tag, rule.Exclude) {
+			continue
+		}
+		kept++
+		if rule.KeepLastN > 0 && kept <= rule.KeepLastN {
+			continue
+		}
+
+		if rule.KeepNewerThan > 0 {
+			manifest, manifestErr := rs.Manifest(ctx, repoName, tag)
+			if manifestErr != nil {
+				return manifestErr
+			}
+			newer, newerErr := createdWithin(ctx, rs, repoName, manifest.Config.Digest, rule.KeepNewerThan)
+			if newerErr != nil {
+				return newerErr
+			}
+			if newer {
+				continue
+			}
+		}
+
+		digest, digestErr := rs.ManifestDigest(ctx, repoName, tag)
+		if digestErr != nil {
+			return digestErr
+		}
+
+		if err = rs.DeleteTag(ctx, repoName, digest); err != nil {
+			return err
+		}
+		if onDelete != nil {
+			onDelete(digest)
+		}
+	}
+
+	return nil
+}
+
+// imageConfig is the subset of the OCI image config blob applyRetention needs
+// to evaluate rule.KeepNewerThan.
+type imageConfig struct {
+	Created time.Time `json:"created"`
+}
+
+// createdWithin reports whether the image config blob identified by configDigest
+// was created more recently than window. A blob that can't be fetched is reported
+// as an error. A blob that can't be parsed or doesn't carry a "created" timestamp
+// is treated as not within the window, so retention falls back to pruning it
+// rather than keeping it indefinitely.
+func createdWithin(ctx context.Context, rs registryInterface, repoName, configDigest string, window time.Duration) (bool, error) {
+	blob, err := rs.GetBlob(ctx, repoName, configDigest)
+	if err != nil {
+		return false, err
+	}
+
+	var cfg imageConfig
+	if err = json.Unmarshal(blob, &cfg); err != nil {
+		return false, nil //nolint:nilerr // a config blob we can't parse just can't prove it's within the window
+	}
+	if cfg.Created.IsZero() {
+		return false, nil
+	}
+
+	return time.Since(cfg.Created) < window, nil
+}
+
+// repoMatches reports whether name matches any of the glob patterns, or true when patterns is empty.
+func repoMatches(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// copyRequest identifies the manifest to duplicate and where to put it.
+type copyRequest struct {
+	Source struct {
+		Repo   string `json:"repo"`
+		Digest string `json:"digest"`
+	} `json:"source"`
+	Dest struct {
+		Repo string `json:"repo"`
+	} `json:"dest"`
+}
+
+// copyCtrl duplicates a manifest (and its blobs, via mount upload) between repositories as a background job.
+func (bh *bulkHandlers) copyCtrl(w http.ResponseWriter, r *http.Request) {
+	var req copyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		R.SendErrorJSON(w, r, bh.l, http.StatusBadRequest, err, "failed to parse copy request")
+		return
+	}
+
+	actor := notifyActor(r)
+	job := bh.jobs.Submit(bh.ctx, "copy", 1, func(ctx context.Context, report jobs.Report) error {
+		err := bh.registryService.CopyManifest(ctx, req.Source.Repo, req.Dest.Repo, req.Source.Digest)
+		report(req.Source.Repo+"@"+req.Source.Digest+" -> "+req.Dest.Repo, err)
+		if err == nil {
+			bh.publish(notify.Event{Type: notify.EventRegistryPush, Repo: req.Dest.Repo, Actor: actor, Data: req.Source.Digest})
+		}
+		return err
+	})
+
+	R.JSON(w, r, responseMessage{Message: "copy job started", ID: job.ID, Data: job})
+}
+
+// jobStatusCtrl reports the progress of a previously submitted background job.
+func (bh *bulkHandlers) jobStatusCtrl(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		R.SendErrorJSON(w, r, bh.l, http.StatusBadRequest, err, "invalid job id")
+		return
+	}
+
+	job, ok := bh.jobs.Get(id)
+	if !ok {
+		R.SendErrorJSON(w, r, bh.l, http.StatusNotFound, errJobNotFound, "job not found")
+		return
+	}
+
+	R.JSON(w, r, job)
+}
+
+// jobCancelCtrl requests cancellation of a previously submitted background job.
+func (bh *bulkHandlers) jobCancelCtrl(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		R.SendErrorJSON(w, r, bh.l, http.StatusBadRequest, err, "invalid job id")
+		return
+	}
+
+	if !bh.jobs.Cancel(id) {
+		R.SendErrorJSON(w, r, bh.l, http.StatusNotFound, errJobNotFound, "job not found")
+		return
+	}
+
+	R.JSON(w, r, responseMessage{Message: "job cancellation requested"})
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zebox/registry-admin/app/registry"
+)
+
+func TestRepoMatches(t *testing.T) {
+	tbl := []struct {
+		name     string
+		repo     string
+		patterns []string
+		want     bool
+	}{
+		{name: "no patterns matches anything", repo: "team/api", patterns: nil, want: true},
+		{name: "exact match", repo: "team/api", patterns: []string{"team/api"}, want: true},
+		{name: "glob match", repo: "team/api", patterns: []string{"team/*"}, want: true},
+		{name: "no match among several patterns", repo: "team/api", patterns: []string{"other/*", "another/*"}, want: false},
+		{name: "one of several patterns matches", repo: "team/api", patterns: []string{"other/*", "team/*"}, want: true},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repoMatches(tt.repo, tt.patterns); got != tt.want {
+				t.Errorf("repoMatches(%q, %v) = %v, want %v", tt.repo, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeBlobRegistry stubs just enough of registryInterface for createdWithin,
+// which only ever calls GetBlob.
+type fakeBlobRegistry struct {
+	registryInterface
+	blob []byte
+	err  error
+}
+
+func (f *fakeBlobRegistry) GetBlob(_ context.Context, _, _ string) ([]byte, error) {
+	return f.blob, f.err
+}
+
+func TestCreatedWithin(t *testing.T) {
+	now := time.Now()
+
+	tbl := []struct {
+		name   string
+		config []byte
+		window time.Duration
+		want   bool
+	}{
+		{
+			name:   "created recently is within the window",
+			config: []byte(`{"created":"` + now.Add(-time.Hour).Format(time.RFC3339) + `"}`),
+			window: 24 * time.Hour,
+			want:   true,
+		},
+		{
+			name:   "created long ago is outside the window",
+			config: []byte(`{"created":"` + now.Add(-30*24*time.Hour).Format(time.RFC3339) + `"}`),
+			window: 24 * time.Hour,
+			want:   false,
+		},
+		{
+			name:   "missing created timestamp is treated as outside the window",
+			config: []byte(`{}`),
+			window: 24 * time.Hour,
+			want:   false,
+		},
+		{
+			name:   "unparseable config blob is treated as outside the window",
+			config: []byte(`not json`),
+			window: 24 * time.Hour,
+			want:   false,
+		},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &fakeBlobRegistry{blob: tt.config}
+			got, err := createdWithin(context.Background(), rs, "repo", "sha256:cfg", tt.window)
+			if err != nil {
+				t.Fatalf("createdWithin: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("createdWithin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRetention_noopWithoutARule(t *testing.T) {
+	rs := &fakeBlobRegistry{}
+	if err := applyRetention(context.Background(), rs, "repo", retentionRule{}, nil); err != nil {
+		t.Fatalf("applyRetention: %v", err)
+	}
+}
+
+// fakeRetentionRegistry exercises applyRetention's deletion path end to end:
+// tags in, manifest digests resolved, DeleteTag called with exactly those
+// digests - never with a config blob digest.
+type fakeRetentionRegistry struct {
+	registryInterface
+	tags           []string
+	digestsByTag   map[string]string
+	deletedDigests []string
+}
+
+func (f *fakeRetentionRegistry) ListingImageTags(_ context.Context, _, _, _ string) (registry.ImageTags, error) {
+	return registry.ImageTags{Tags: f.tags}, nil
+}
+
+func (f *fakeRetentionRegistry) Manifest(_ context.Context, _, _ string) (registry.ManifestSchemaV2, error) {
+	return registry.ManifestSchemaV2{}, nil
+}
+
+func (f *fakeRetentionRegistry) ManifestDigest(_ context.Context, _, ref string) (string, error) {
+	return f.digestsByTag[ref], nil
+}
+
+func (f *fakeRetentionRegistry) DeleteTag(_ context.Context, _, digest string) error {
+	f.deletedDigests = append(f.deletedDigests, digest)
+	return nil
+}
+
+func TestApplyRetention_deletesByManifestDigestNotConfigDigest(t *testing.T) {
+	rs := &fakeRetentionRegistry{
+		tags: []string{"v3", "v2", "v1"}, // newest-first, as ListingImageTags is documented to return them
+		digestsByTag: map[string]string{
+			"v3": "sha256:manifest-v3",
+			"v2": "sha256:manifest-v2",
+			"v1": "sha256:manifest-v1",
+		},
+	}
+
+	var published []string
+	onDelete := func(digest string) { published = append(published, digest) }
+
+	if err := applyRetention(context.Background(), rs, "repo", retentionRule{KeepLastN: 1}, onDelete); err != nil {
+		t.Fatalf("applyRetention: %v", err)
+	}
+
+	want := []string{"sha256:manifest-v2", "sha256:manifest-v1"}
+	if len(rs.deletedDigests) != len(want) {
+		t.Fatalf("DeleteTag called with %v, want %v", rs.deletedDigests, want)
+	}
+	for i, digest := range want {
+		if rs.deletedDigests[i] != digest {
+			t.Errorf("DeleteTag[%d] = %q, want %q", i, rs.deletedDigests[i], digest)
+		}
+		if published[i] != digest {
+			t.Errorf("onDelete[%d] = %q, want %q", i, published[i], digest)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zebox/registry-admin/app/store/engine"
+)
+
+// healthStatus is the outcome of a single health checker run.
+type healthStatus struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // "ok" or "fail"
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency"`
+}
+
+// healthChecker is implemented by every dependency the /health endpoint reports on.
+type healthChecker interface {
+	// name identifies the checker in the aggregated response, e.g. "storage", "registry", "htpasswd".
+	name() string
+	// check reports whether the dependency is currently healthy.
+	check(ctx context.Context) error
+}
+
+// healthRegistry aggregates a set of healthCheckers behind a single handler,
+// following the same "named checker registry" pattern the distribution
+// project uses for its /debug/health endpoint.
+type healthRegistry struct {
+	mu       sync.RWMutex
+	checkers []healthChecker
+}
+
+// register adds a checker to the registry. Safe to call concurrently with handler.
+func (h *healthRegistry) register(c healthChecker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers = append(h.checkers, c)
+}
+
+// handler runs every registered checker and reports an aggregated status.
+// The endpoint returns 200 when every checker passes and 503 otherwise, so it
+// can be used directly as a load balancer / orchestrator liveness probe.
+func (h *healthRegistry) handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.mu.RLock()
+		checkers := make([]healthChecker, len(h.checkers))
+		copy(checkers, h.checkers)
+		h.mu.RUnlock()
+
+		results := make([]healthStatus, len(checkers))
+		healthy := true
+
+		for i, c := range checkers {
+			start := time.Now()
+			err := c.check(r.Context())
+			results[i] = healthStatus{Name: c.name(), Status: "ok", Latency: time.Since(start).String()}
+			if err != nil {
+				healthy = false
+				results[i].Status = "fail"
+				results[i].Error = err.Error()
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(struct {
+			Status string         `json:"status"`
+			Checks []healthStatus `json:"checks"`
+		}{Status: map[bool]string{true: "ok", false: "fail"}[healthy], Checks: results})
+	}
+}
+
+// storagePinger is implemented by storage engines that support a lightweight
+// connectivity check. engine.Interface implementations are not required to
+// support it; when they don't, the storage checker is reported healthy.
+type storagePinger interface {
+	Ping(ctx context.Context) error
+}
+
+// storageHealthChecker reports whether the storage engine is reachable.
+type storageHealthChecker struct {
+	storage engine.Interface
+}
+
+func (c *storageHealthChecker) name() string { return "storage" }
+
+func (c *storageHealthChecker) check(ctx context.Context) error {
+	pinger, ok := c.storage.(storagePinger)
+	if !ok {
+		return nil
+	}
+	return pinger.Ping(ctx)
+}
+
+// registryHealthChecker reports whether the upstream Docker Registry answers the v2 API check.
+type registryHealthChecker struct {
+	registry registryInterface
+}
+
+func (c *registryHealthChecker) name() string { return "registry" }
+
+func (c *registryHealthChecker) check(ctx context.Context) error {
+	return c.registry.APIVersionCheck(ctx)
+}
+
+// htpasswdStatusChecker is implemented by a registryInterface that can report whether
+// its htpasswd file is currently writable.
+type htpasswdStatusChecker interface {
+	HtpasswdStatus() error
+}
+
+// htpasswdHealthChecker reports whether the htpasswd file backing basic auth can be written.
+type htpasswdHealthChecker struct {
+	registry registryInterface
+}
+
+func (c *htpasswdHealthChecker) name() string { return "htpasswd" }
+
+func (c *htpasswdHealthChecker) check(_ context.Context) error {
+	checker, ok := c.registry.(htpasswdStatusChecker)
+	if !ok {
+		return nil
+	}
+	return checker.HtpasswdStatus()
+}
+
+// newHealthRegistry builds the default set of health checkers for a running Server.
+func newHealthRegistry(storage engine.Interface, rs registryInterface) *healthRegistry {
+	hr := &healthRegistry{}
+	hr.register(&storageHealthChecker{storage: storage})
+	hr.register(&registryHealthChecker{registry: rs})
+	hr.register(&htpasswdHealthChecker{registry: rs})
+	return hr
+}
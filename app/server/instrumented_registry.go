@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/zebox/registry-admin/app/registry"
+)
+
+// instrumentedRegistry wraps a registryInterface and records
+// registry_calls_total/registry_call_duration_seconds/registry_call_errors_total
+// for every catalog, tags, manifest, manifest digest, manifest index, blob,
+// delete and copy call, regardless of whether pull-through proxy mode
+// (proxyRegistry) is enabled; the other registryInterface methods pass
+// through uninstrumented. Without this wrapper those metrics only ever fired for the
+// upstream fetches proxyRegistry makes, leaving the primary, always-on
+// catalog/manifest/delete path uninstrumented on a default install.
+type instrumentedRegistry struct {
+	registryInterface
+	metrics *metrics
+}
+
+// newInstrumentedRegistry wraps local with m. m must not be nil.
+func newInstrumentedRegistry(local registryInterface, m *metrics) *instrumentedRegistry {
+	return &instrumentedRegistry{registryInterface: local, metrics: m}
+}
+
+func (r *instrumentedRegistry) Catalog(ctx context.Context, n, last string) (repos registry.Repositories, err error) {
+	defer r.metrics.observeRegistryCall("catalog", time.Now())(&err)
+	return r.registryInterface.Catalog(ctx, n, last)
+}
+
+func (r *instrumentedRegistry) ListingImageTags(ctx context.Context, repoName, n, last string) (tags registry.ImageTags, err error) {
+	defer r.metrics.observeRegistryCall("tags", time.Now())(&err)
+	return r.registryInterface.ListingImageTags(ctx, repoName, n, last)
+}
+
+func (r *instrumentedRegistry) Manifest(ctx context.Context, repoName, tag string) (manifest registry.ManifestSchemaV2, err error) {
+	defer r.metrics.observeRegistryCall("manifest", time.Now())(&err)
+	return r.registryInterface.Manifest(ctx, repoName, tag)
+}
+
+func (r *instrumentedRegistry) ManifestDigest(ctx context.Context, repoName, ref string) (digest string, err error) {
+	defer r.metrics.observeRegistryCall("manifest_digest", time.Now())(&err)
+	return r.registryInterface.ManifestDigest(ctx, repoName, ref)
+}
+
+func (r *instrumentedRegistry) ManifestIndex(ctx context.Context, repoName, ref string) (index registry.ManifestIndex, err error) {
+	defer r.metrics.observeRegistryCall("manifest_index", time.Now())(&err)
+	return r.registryInterface.ManifestIndex(ctx, repoName, ref)
+}
+
+func (r *instrumentedRegistry) GetBlob(ctx context.Context, name, digest string) (blob []byte, err error) {
+	defer r.metrics.observeRegistryCall("blob", time.Now())(&err)
+	return r.registryInterface.GetBlob(ctx, name, digest)
+}
+
+func (r *instrumentedRegistry) DeleteTag(ctx context.Context, repoName, digest string) (err error) {
+	defer r.metrics.observeRegistryCall("delete", time.Now())(&err)
+	return r.registryInterface.DeleteTag(ctx, repoName, digest)
+}
+
+func (r *instrumentedRegistry) CopyManifest(ctx context.Context, srcRepo, dstRepo, digest string) (err error) {
+	defer r.metrics.observeRegistryCall("copy", time.Now())(&err)
+	return r.registryInterface.CopyManifest(ctx, srcRepo, dstRepo, digest)
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	R "github.com/go-pkgz/rest"
+	"github.com/pkg/errors"
+
+	"github.com/zebox/registry-admin/app/notify"
+	"github.com/zebox/registry-admin/app/registry"
+)
+
+// errMissingQueryParams is returned when a required query parameter is absent.
+var errMissingQueryParams = errors.New("missing required query parameters")
+
+// manifestIndexHandlers expose the per-platform view of multi-arch repositories
+// (OCI image indexes / Docker manifest lists) so the frontend can render a
+// platform matrix and admins can cascade-delete every manifest an index refers to.
+type manifestIndexHandlers struct {
+	endpointsHandler
+	registryService registryInterface
+}
+
+// platformManifest is a single platform's entry in the index, with its image
+// config blob resolved so the frontend doesn't need to walk the index itself.
+type platformManifest struct {
+	Digest   string            `json:"digest"`
+	Platform registry.Platform `json:"platform"`
+	Config   json.RawMessage   `json:"config,omitempty"`
+}
+
+// manifestIndexCtrl returns the platform matrix for a multi-arch repository:ref.
+func (mh *manifestIndexHandlers) manifestIndexCtrl(w http.ResponseWriter, r *http.Request) {
+	repoName, ref := r.URL.Query().Get("repo"), r.URL.Query().Get("ref")
+	if repoName == "" || ref == "" {
+		R.SendErrorJSON(w, r, mh.l, http.StatusBadRequest, errMissingQueryParams, "'repo' and 'ref' query parameters are required")
+		return
+	}
+
+	index, err := mh.registryService.ManifestIndex(r.Context(), repoName, ref)
+	if err != nil {
+		R.SendErrorJSON(w, r, mh.l, http.StatusInternalServerError, err, "failed to load manifest index")
+		return
+	}
+
+	platforms := make([]platformManifest, 0, len(index.Manifests))
+	for _, entry := range index.Manifests {
+		manifest, manifestErr := mh.registryService.Manifest(r.Context(), repoName, entry.Digest)
+		if manifestErr != nil {
+			mh.l.Logf("[WARN] failed to load platform manifest %s for %s: %v", entry.Digest, repoName, manifestErr)
+			platforms = append(platforms, platformManifest{Digest: entry.Digest, Platform: entry.Platform})
+			continue
+		}
+
+		blob, blobErr := mh.registryService.GetBlob(r.Context(), repoName, manifest.Config.Digest)
+		if blobErr != nil {
+			mh.l.Logf("[WARN] failed to load config blob for platform manifest %s of %s: %v", entry.Digest, repoName, blobErr)
+		}
+
+		platforms = append(platforms, platformManifest{Digest: entry.Digest, Platform: entry.Platform, Config: blob})
+	}
+
+	R.JSON(w, r, R.JSON{"data": platforms})
+}
+
+// cascadeDeleteIndexCtrl removes the manifest index identified by 'repo'/'digest' and, when
+// cascade=true, every platform-specific manifest it references. A plain digest delete only
+// removes the index entry and would otherwise orphan the manifests it fans out to.
+func (mh *manifestIndexHandlers) cascadeDeleteIndexCtrl(w http.ResponseWriter, r *http.Request) {
+	repoName, digest := r.URL.Query().Get("repo"), r.URL.Query().Get("digest")
+	if repoName == "" || digest == "" {
+		R.SendErrorJSON(w, r, mh.l, http.StatusBadRequest, errMissingQueryParams, "'repo' and 'digest' query parameters are required")
+		return
+	}
+
+	actor := notifyActor(r)
+
+	if r.URL.Query().Get("cascade") == "true" {
+		index, err := mh.registryService.ManifestIndex(r.Context(), repoName, digest)
+		if err != nil {
+			R.SendErrorJSON(w, r, mh.l, http.StatusInternalServerError, err, "failed to load manifest index for cascade delete")
+			return
+		}
+
+		for _, entry := range index.Manifests {
+			if err = mh.registryService.DeleteTag(r.Context(), repoName, entry.Digest); err != nil {
+				R.SendErrorJSON(w, r, mh.l, http.StatusInternalServerError, err, "failed to cascade-delete platform manifest")
+				return
+			}
+			mh.publish(notify.Event{Type: notify.EventRegistryDelete, Repo: repoName, Actor: actor, Data: entry.Digest})
+		}
+	}
+
+	if err := mh.registryService.DeleteTag(r.Context(), repoName, digest); err != nil {
+		R.SendErrorJSON(w, r, mh.l, http.StatusInternalServerError, err, "failed to delete manifest index")
+		return
+	}
+	mh.publish(notify.Event{Type: notify.EventRegistryDelete, Repo: repoName, Actor: actor, Data: digest})
+
+	R.JSON(w, r, responseMessage{Message: "manifest index deleted"})
+}
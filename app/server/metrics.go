@@ -0,0 +1,196 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsConfig controls how the Prometheus metrics endpoint is exposed.
+type MetricsConfig struct {
+	Enabled    bool   // enable /metrics endpoint and request instrumentation
+	ListenAddr string // when set, /metrics is served on this separate address instead of the main router
+}
+
+// metrics keeps all the Prometheus collectors registered by the server.
+// A single instance is created per Server so tests and multiple server
+// instances don't clash on the default registry.
+type metrics struct {
+	registry *prometheus.Registry
+
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+
+	authFailuresTotal prometheus.Counter
+	rbacDeniedTotal   *prometheus.CounterVec
+
+	registryCallsTotal   *prometheus.CounterVec
+	registryCallDuration *prometheus.HistogramVec
+	registryCallErrors   *prometheus.CounterVec
+
+	gcRunsTotal       prometheus.Counter
+	gcDuration        prometheus.Histogram
+	gcErrorsTotal     prometheus.Counter
+	htpasswdSyncTotal *prometheus.CounterVec
+}
+
+// newMetrics creates and registers all the collectors used across the server.
+func newMetrics() *metrics {
+	m := &metrics{registry: prometheus.NewRegistry()}
+
+	m.requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "registry_admin",
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests processed, labeled by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	m.requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "registry_admin",
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency in seconds, labeled by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	m.authFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "registry_admin",
+		Name:      "auth_failures_total",
+		Help:      "Total number of failed authentication attempts.",
+	})
+
+	m.rbacDeniedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "registry_admin",
+		Name:      "rbac_denied_total",
+		Help:      "Total number of requests rejected by RBAC, labeled by route.",
+	}, []string{"route"})
+
+	m.registryCallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "registry_admin",
+		Name:      "registry_calls_total",
+		Help:      "Total number of calls made to the Docker Registry API - the local registry and, in proxy mode, also the upstream registry - labeled by operation and outcome.",
+	}, []string{"operation", "outcome"})
+
+	m.registryCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "registry_admin",
+		Name:      "registry_call_duration_seconds",
+		Help:      "Latency of calls made to the Docker Registry API - the local registry and, in proxy mode, also the upstream registry - labeled by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	m.registryCallErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "registry_admin",
+		Name:      "registry_call_errors_total",
+		Help:      "Total number of failed calls to the Docker Registry API - the local registry and, in proxy mode, also the upstream registry - labeled by operation.",
+	}, []string{"operation"})
+
+	m.gcRunsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "registry_admin",
+		Name:      "gc_runs_total",
+		Help:      "Total number of garbage collector runs.",
+	})
+
+	m.gcDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "registry_admin",
+		Name:      "gc_duration_seconds",
+		Help:      "Duration of garbage collector runs in seconds.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+	})
+
+	m.gcErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "registry_admin",
+		Name:      "gc_errors_total",
+		Help:      "Total number of garbage collector runs that finished with an error.",
+	})
+
+	m.htpasswdSyncTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "registry_admin",
+		Name:      "htpasswd_sync_total",
+		Help:      "Total number of htpasswd file sync attempts, labeled by outcome (success/error).",
+	}, []string{"outcome"})
+
+	m.registry.MustRegister(
+		m.requestsTotal,
+		m.requestDuration,
+		m.authFailuresTotal,
+		m.rbacDeniedTotal,
+		m.registryCallsTotal,
+		m.registryCallDuration,
+		m.registryCallErrors,
+		m.gcRunsTotal,
+		m.gcDuration,
+		m.gcErrorsTotal,
+		m.htpasswdSyncTotal,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// middleware instruments every request that passes through the chi router with
+// request counts and latencies, labeled by the matched route pattern so that
+// cardinality stays bounded regardless of path parameters (e.g. user ids). It
+// also derives authFailuresTotal/rbacDeniedTotal from the response status the
+// auth middleware/RBAC check produced, since both run inside next.ServeHTTP.
+func (m *metrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middlewareStatusRecorder(w)
+		next.ServeHTTP(ww, r)
+
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		m.requestsTotal.WithLabelValues(route, r.Method, strconv.Itoa(ww.status)).Inc()
+		m.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+
+		switch ww.status {
+		case http.StatusUnauthorized:
+			m.authFailuresTotal.Inc()
+		case http.StatusForbidden:
+			m.rbacDeniedTotal.WithLabelValues(route).Inc()
+		}
+	})
+}
+
+// statusRecorder wraps http.ResponseWriter to capture the status code written
+// by downstream handlers, defaulting to 200 if WriteHeader is never called.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func middlewareStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// handler returns the http.Handler serving metrics in the Prometheus text format.
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// observeRegistryCall records the outcome and latency of a call to the Docker
+// Registry API (local, or upstream in proxy mode). Call sites wrap registryInterface calls with it, e.g.:
+//
+//	defer m.observeRegistryCall("catalog", time.Now())(&err)
+func (m *metrics) observeRegistryCall(operation string, start time.Time) func(errp *error) {
+	return func(errp *error) {
+		m.registryCallDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+		outcome := "success"
+		if errp != nil && *errp != nil {
+			outcome = "error"
+			m.registryCallErrors.WithLabelValues(operation).Inc()
+		}
+		m.registryCallsTotal.WithLabelValues(operation, outcome).Inc()
+	}
+}
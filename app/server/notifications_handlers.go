@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	R "github.com/go-pkgz/rest"
+
+	"github.com/zebox/registry-admin/app/notify"
+)
+
+// notificationsHandlers expose CRUD endpoints for managing webhook notification endpoints.
+type notificationsHandlers struct {
+	endpointsHandler
+	broadcaster *notify.Broadcaster
+}
+
+// notificationEndpointsListCtrl return all configured notification endpoints.
+func (nh *notificationsHandlers) notificationEndpointsListCtrl(w http.ResponseWriter, r *http.Request) {
+	R.JSON(w, r, R.JSON{"data": nh.broadcaster.ListEndpoints()})
+}
+
+// notificationEndpointCreateCtrl register a new notification endpoint.
+func (nh *notificationsHandlers) notificationEndpointCreateCtrl(w http.ResponseWriter, r *http.Request) {
+	var endpoint notify.Endpoint
+	if err := json.NewDecoder(r.Body).Decode(&endpoint); err != nil {
+		R.SendErrorJSON(w, r, nh.l, http.StatusBadRequest, err, "failed to parse notification endpoint request")
+		return
+	}
+
+	created, err := nh.broadcaster.AddEndpoint(endpoint)
+	if err != nil {
+		R.SendErrorJSON(w, r, nh.l, http.StatusBadRequest, err, "failed to create notification endpoint")
+		return
+	}
+
+	R.JSON(w, r, responseMessage{Message: "notification endpoint created", ID: created.ID, Data: created})
+}
+
+// notificationEndpointUpdateCtrl update an existing notification endpoint identified by {id}.
+func (nh *notificationsHandlers) notificationEndpointUpdateCtrl(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		R.SendErrorJSON(w, r, nh.l, http.StatusBadRequest, err, "invalid notification endpoint id")
+		return
+	}
+
+	var endpoint notify.Endpoint
+	if err = json.NewDecoder(r.Body).Decode(&endpoint); err != nil {
+		R.SendErrorJSON(w, r, nh.l, http.StatusBadRequest, err, "failed to parse notification endpoint request")
+		return
+	}
+	endpoint.ID = id
+
+	updated, err := nh.broadcaster.UpdateEndpoint(endpoint)
+	if err != nil {
+		R.SendErrorJSON(w, r, nh.l, http.StatusBadRequest, err, "failed to update notification endpoint")
+		return
+	}
+
+	R.JSON(w, r, responseMessage{Message: "notification endpoint updated", ID: updated.ID, Data: updated})
+}
+
+// notificationEndpointDeleteCtrl remove the notification endpoint identified by {id}.
+func (nh *notificationsHandlers) notificationEndpointDeleteCtrl(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		R.SendErrorJSON(w, r, nh.l, http.StatusBadRequest, err, "invalid notification endpoint id")
+		return
+	}
+
+	if err = nh.broadcaster.RemoveEndpoint(id); err != nil {
+		R.SendErrorJSON(w, r, nh.l, http.StatusBadRequest, err, "failed to delete notification endpoint")
+		return
+	}
+
+	R.JSON(w, r, responseMessage{Message: "notification endpoint deleted", ID: id})
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	R "github.com/go-pkgz/rest"
+)
+
+// proxyHandlers exposes admin endpoints for managing the pull-through
+// registry cache. Only mounted when the server is running in proxy mode.
+type proxyHandlers struct {
+	endpointsHandler
+	proxy *proxyRegistry
+}
+
+// proxyInvalidateRequest names the repository whose cached entries should be
+// dropped. An empty Repo invalidates the entire cache.
+type proxyInvalidateRequest struct {
+	Repo string `json:"repo"`
+}
+
+// proxyInvalidateCtrl drops cached upstream responses so the next read re-fetches them.
+func (ph *proxyHandlers) proxyInvalidateCtrl(w http.ResponseWriter, r *http.Request) {
+	var req proxyInvalidateRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			R.SendErrorJSON(w, r, ph.l, http.StatusBadRequest, err, "failed to parse proxy invalidate request")
+			return
+		}
+	}
+
+	ph.proxy.invalidate(req.Repo)
+	R.JSON(w, r, responseMessage{Message: "proxy cache invalidated"})
+}
+
+// proxyTTLRequest overrides the cache freshness window.
+type proxyTTLRequest struct {
+	TTL string `json:"ttl"` // duration string, e.g. "10m"
+}
+
+// proxyTTLCtrl updates how long cached upstream responses are considered fresh.
+func (ph *proxyHandlers) proxyTTLCtrl(w http.ResponseWriter, r *http.Request) {
+	var req proxyTTLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		R.SendErrorJSON(w, r, ph.l, http.StatusBadRequest, err, "failed to parse proxy ttl request")
+		return
+	}
+
+	ttl, err := time.ParseDuration(req.TTL)
+	if err != nil {
+		R.SendErrorJSON(w, r, ph.l, http.StatusBadRequest, err, "invalid ttl duration")
+		return
+	}
+
+	ph.proxy.setTTL(ttl)
+	R.JSON(w, r, responseMessage{Message: "proxy cache ttl updated"})
+}
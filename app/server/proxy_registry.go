@@ -0,0 +1,532 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/zebox/registry-admin/app/registry"
+	"github.com/zebox/registry-admin/app/registry/challenge"
+	"github.com/zebox/registry-admin/app/store/engine"
+
+	log "github.com/go-pkgz/lgr"
+)
+
+// ProxyConfig configures a pull-through cache in front of a remote registry
+// (e.g. Docker Hub, gcr.io). Leaving RemoteURL empty disables proxy mode.
+type ProxyConfig struct {
+	RemoteURL string // base URL of the upstream registry
+	Username  string // credentials used for the Basic/Bearer auth flow against the upstream
+	Password  string
+	TTL       time.Duration // how long a cached catalog/manifest entry is considered fresh, default used when zero
+}
+
+const defaultProxyTTL = 5 * time.Minute
+
+// repositoryRecorder is implemented by engine.Interface implementations that can
+// persist repositories discovered while proxying to an upstream registry.
+// Implementations that don't support it are simply not recorded locally.
+type repositoryRecorder interface {
+	CreateRepository(ctx context.Context, name string) error
+}
+
+// cacheEntry holds a cached upstream response alongside the time it was fetched.
+// Only the field relevant to the cache it's stored in is populated.
+type cacheEntry struct {
+	fetchedAt time.Time
+	repos     registry.Repositories
+	tags      registry.ImageTags
+	manifest  registry.ManifestSchemaV2
+	blob      []byte
+}
+
+func (e *cacheEntry) fresh(ttl time.Duration) bool {
+	return e != nil && time.Since(e.fetchedAt) < ttl
+}
+
+// proxyRegistry wraps a local registryInterface and turns it into a pull-through
+// cache: reads that miss locally fall through to RemoteURL, authenticating via
+// the challenge package, and the result is cached for TTL and recorded in the
+// local storage so it shows up in future catalog listings. This is analogous to
+// the distribution project's registry/proxy package.
+type proxyRegistry struct {
+	registryInterface
+	cfg          ProxyConfig
+	storage      engine.Interface
+	client       *http.Client
+	manager      *challenge.Manager
+	authHandlers []challenge.AuthHandler // tried in order against whichever scheme the upstream challenged with
+	metrics      *metrics                // nil when metrics collection is disabled
+
+	mu            sync.RWMutex
+	ttl           time.Duration
+	catalog       *cacheEntry
+	tagsByKey     map[string]*cacheEntry
+	manifestByKey map[string]*cacheEntry
+	blobByKey     map[string]*cacheEntry
+	proxiedRepos  map[string]bool // repos discovered only through the proxy cache, independent of which pagination params fetched them
+}
+
+// newProxyRegistry builds a proxyRegistry fronting cfg.RemoteURL for cache
+// misses on local. cfg.TTL of zero falls back to defaultProxyTTL. m may be
+// nil, in which case upstream calls aren't instrumented.
+func newProxyRegistry(local registryInterface, storage engine.Interface, cfg ProxyConfig, m *metrics) *proxyRegistry {
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultProxyTTL
+	}
+
+	p := &proxyRegistry{
+		registryInterface: local,
+		cfg:               cfg,
+		storage:           storage,
+		client:            &http.Client{Timeout: 30 * time.Second},
+		manager:           challenge.NewManager(),
+		metrics:           m,
+		ttl:               ttl,
+		tagsByKey:         map[string]*cacheEntry{},
+		manifestByKey:     map[string]*cacheEntry{},
+		blobByKey:         map[string]*cacheEntry{},
+		proxiedRepos:      map[string]bool{},
+	}
+	// Bearer is tried first: Docker Hub and gcr.io, the two upstreams this
+	// mode is built against, both challenge with Bearer rather than Basic.
+	p.authHandlers = []challenge.AuthHandler{
+		&challenge.BearerHandler{Fetch: p.fetchUpstreamToken},
+		&challenge.BasicHandler{Username: cfg.Username, Password: cfg.Password},
+	}
+	return p
+}
+
+// Catalog merges the local catalog with repositories discovered from the
+// upstream, refreshing the upstream listing once it goes stale.
+func (p *proxyRegistry) Catalog(ctx context.Context, n, last string) (registry.Repositories, error) {
+	local, err := p.registryInterface.Catalog(ctx, n, last)
+	if err != nil {
+		return local, err
+	}
+
+	p.mu.RLock()
+	fresh := p.catalog.fresh(p.ttl)
+	p.mu.RUnlock()
+	if fresh {
+		return p.mergeCatalog(local), nil
+	}
+
+	upstream, err := p.fetchUpstreamCatalog(ctx, n, last)
+	if err != nil {
+		// a stale or unreachable upstream shouldn't fail the request, local results still stand
+		return local, nil //nolint:nilerr // degrade to local-only catalog on upstream error
+	}
+
+	p.mu.Lock()
+	p.catalog = &cacheEntry{fetchedAt: time.Now(), repos: upstream}
+	p.mu.Unlock()
+
+	p.recordDiscovered(ctx, upstream)
+
+	return p.mergeCatalog(local), nil
+}
+
+// ListingImageTags serves from local storage first, falling through to the
+// upstream and caching the result for TTL when the repo isn't known locally.
+func (p *proxyRegistry) ListingImageTags(ctx context.Context, repoName, n, last string) (registry.ImageTags, error) {
+	local, err := p.registryInterface.ListingImageTags(ctx, repoName, n, last)
+	if err == nil {
+		return local, nil
+	}
+
+	key := repoName + "|" + n + "|" + last
+	p.mu.RLock()
+	entry := p.tagsByKey[key]
+	p.mu.RUnlock()
+	if entry.fresh(p.ttl) {
+		return entry.tags, nil
+	}
+
+	tags, fetchErr := p.fetchUpstreamTags(ctx, repoName, n, last)
+	if fetchErr != nil {
+		return local, err // report the original local error, the upstream fetch added no new information
+	}
+
+	p.mu.Lock()
+	p.tagsByKey[key] = &cacheEntry{fetchedAt: time.Now(), tags: tags}
+	p.mu.Unlock()
+
+	p.recordDiscovered(ctx, registry.Repositories{Repositories: []string{repoName}})
+
+	return tags, nil
+}
+
+// Manifest serves from local storage first, falling through to the upstream
+// and caching the result for TTL when the manifest isn't known locally.
+func (p *proxyRegistry) Manifest(ctx context.Context, repoName, tag string) (registry.ManifestSchemaV2, error) {
+	local, err := p.registryInterface.Manifest(ctx, repoName, tag)
+	if err == nil {
+		return local, nil
+	}
+
+	key := repoName + "|" + tag
+	p.mu.RLock()
+	entry := p.manifestByKey[key]
+	p.mu.RUnlock()
+	if entry.fresh(p.ttl) {
+		return entry.manifest, nil
+	}
+
+	manifest, fetchErr := p.fetchUpstreamManifest(ctx, repoName, tag)
+	if fetchErr != nil {
+		return local, err // report the original local error, the upstream fetch added no new information
+	}
+
+	p.mu.Lock()
+	p.manifestByKey[key] = &cacheEntry{fetchedAt: time.Now(), manifest: manifest}
+	p.mu.Unlock()
+
+	p.recordDiscovered(ctx, registry.Repositories{Repositories: []string{repoName}})
+
+	return manifest, nil
+}
+
+// GetBlob serves from local storage first, falling through to the upstream
+// and caching the result for TTL when the blob isn't known locally.
+func (p *proxyRegistry) GetBlob(ctx context.Context, repoName, digest string) ([]byte, error) {
+	local, err := p.registryInterface.GetBlob(ctx, repoName, digest)
+	if err == nil {
+		return local, nil
+	}
+
+	key := repoName + "|" + digest
+	p.mu.RLock()
+	entry := p.blobByKey[key]
+	p.mu.RUnlock()
+	if entry.fresh(p.ttl) {
+		return entry.blob, nil
+	}
+
+	blob, fetchErr := p.fetchUpstreamBlob(ctx, repoName, digest)
+	if fetchErr != nil {
+		return local, err // report the original local error, the upstream fetch added no new information
+	}
+
+	p.mu.Lock()
+	p.blobByKey[key] = &cacheEntry{fetchedAt: time.Now(), blob: blob}
+	p.mu.Unlock()
+
+	return blob, nil
+}
+
+// DeleteTag refuses to delete tags that only exist as a pull-through cache of
+// the upstream: there is nothing authoritative to delete, and keeping mirrored
+// repos read-only avoids admins accidentally diverging the cache from its source.
+func (p *proxyRegistry) DeleteTag(ctx context.Context, repoName, digest string) error {
+	if p.isProxiedOnly(ctx, repoName) {
+		return errors.Errorf("repository %q is a read-only pull-through mirror of %s", repoName, p.cfg.RemoteURL)
+	}
+	return p.registryInterface.DeleteTag(ctx, repoName, digest)
+}
+
+// isProxiedOnly reports whether repoName is known to the local registry only
+// because it was discovered through the proxy cache.
+func (p *proxyRegistry) isProxiedOnly(ctx context.Context, repoName string) bool {
+	if _, err := p.registryInterface.ListingImageTags(ctx, repoName, "", ""); err == nil {
+		return false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.proxiedRepos[repoName] {
+		return true
+	}
+	return p.catalog != nil && containsRepo(p.catalog.repos, repoName)
+}
+
+// invalidate drops the cached upstream responses for repoName, or every cached
+// response (including the catalog) when repoName is empty, forcing the next read to re-fetch.
+func (p *proxyRegistry) invalidate(repoName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if repoName == "" {
+		p.catalog = nil
+		p.tagsByKey = map[string]*cacheEntry{}
+		p.manifestByKey = map[string]*cacheEntry{}
+		p.blobByKey = map[string]*cacheEntry{}
+		p.proxiedRepos = map[string]bool{}
+		return
+	}
+
+	for key := range p.tagsByKey {
+		if strings.HasPrefix(key, repoName+"|") {
+			delete(p.tagsByKey, key)
+		}
+	}
+	for key := range p.manifestByKey {
+		if strings.HasPrefix(key, repoName+"|") {
+			delete(p.manifestByKey, key)
+		}
+	}
+	for key := range p.blobByKey {
+		if strings.HasPrefix(key, repoName+"|") {
+			delete(p.blobByKey, key)
+		}
+	}
+}
+
+// setTTL overrides the cache freshness window. Freshness is checked against the
+// current window on every read, so the new value applies to entries already cached too.
+func (p *proxyRegistry) setTTL(ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ttl = ttl
+}
+
+func (p *proxyRegistry) fetchUpstreamCatalog(ctx context.Context, n, last string) (registry.Repositories, error) {
+	var repos registry.Repositories
+	reqURL := withPagination(fmt.Sprintf("%s/v2/_catalog", p.cfg.RemoteURL), n, last)
+	return repos, p.doUpstream(ctx, "catalog", reqURL, &repos)
+}
+
+func (p *proxyRegistry) fetchUpstreamTags(ctx context.Context, repoName, n, last string) (registry.ImageTags, error) {
+	var tags registry.ImageTags
+	reqURL := withPagination(fmt.Sprintf("%s/v2/%s/tags/list", p.cfg.RemoteURL, repoName), n, last)
+	return tags, p.doUpstream(ctx, "tags", reqURL, &tags)
+}
+
+func (p *proxyRegistry) fetchUpstreamManifest(ctx context.Context, repoName, tag string) (registry.ManifestSchemaV2, error) {
+	var manifest registry.ManifestSchemaV2
+	reqURL := fmt.Sprintf("%s/v2/%s/manifests/%s", p.cfg.RemoteURL, repoName, tag)
+	return manifest, p.doUpstream(ctx, "manifest", reqURL, &manifest)
+}
+
+func (p *proxyRegistry) fetchUpstreamBlob(ctx context.Context, repoName, digest string) ([]byte, error) {
+	reqURL := fmt.Sprintf("%s/v2/%s/blobs/%s", p.cfg.RemoteURL, repoName, digest)
+	return p.doUpstreamBytes(ctx, "blob", reqURL)
+}
+
+// withPagination appends the registry v2 "n"/"last" pagination query
+// parameters to rawURL when set, so proxied catalog/tag listings honor the
+// same paging the caller asked the local registry for.
+func withPagination(rawURL, n, last string) string {
+	if n == "" && last == "" {
+		return rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	q := u.Query()
+	if n != "" {
+		q.Set("n", n)
+	}
+	if last != "" {
+		q.Set("last", last)
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// doUpstream performs an authenticated GET against the upstream registry and
+// decodes the JSON response into out, a pointer. operation labels the
+// registry_calls_total/registry_call_duration_seconds/registry_call_errors_total
+// metrics.
+func (p *proxyRegistry) doUpstream(ctx context.Context, operation, url string, out interface{}) (err error) {
+	resp, err := p.doUpstreamRequest(ctx, operation, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// doUpstreamBytes performs an authenticated GET against the upstream registry
+// and returns the raw response body, for blobs that aren't necessarily JSON.
+func (p *proxyRegistry) doUpstreamBytes(ctx context.Context, operation, url string) ([]byte, error) {
+	resp, err := p.doUpstreamRequest(ctx, operation, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read upstream response body")
+	}
+	return body, nil
+}
+
+// doUpstreamRequest performs an authenticated GET against the upstream
+// registry, retrying once after recording any WWW-Authenticate challenge the
+// upstream responded with. The caller is responsible for closing the
+// returned response's body. operation labels the registry_calls_total/
+// registry_call_duration_seconds/registry_call_errors_total metrics.
+func (p *proxyRegistry) doUpstreamRequest(ctx context.Context, operation, url string) (_ *http.Response, err error) {
+	if p.metrics != nil {
+		defer p.metrics.observeRegistryCall(operation, time.Now())(&err)
+	}
+
+	var req *http.Request
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build upstream proxy request")
+	}
+
+	_ = p.manager.Authorize(ctx, p.cfg.RemoteURL, req, p.authHandlers...) // ignore error, auth may not be required yet
+
+	var resp *http.Response
+	resp, err = p.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to call upstream registry %s", p.cfg.RemoteURL)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close() //nolint:errcheck,gosec
+		p.manager.AddResponse(p.cfg.RemoteURL, resp)
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to rebuild upstream proxy request")
+		}
+		if err = p.manager.Authorize(ctx, p.cfg.RemoteURL, req, p.authHandlers...); err != nil {
+			return nil, errors.Wrap(err, "failed to authorize request against upstream registry")
+		}
+
+		resp, err = p.client.Do(req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to call upstream registry %s", p.cfg.RemoteURL)
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close() //nolint:errcheck,gosec
+		return nil, errors.Errorf("upstream registry %s responded with status %d", p.cfg.RemoteURL, resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+// tokenResponse is the subset of a Bearer token server's response body this
+// client cares about. Docker Hub and gcr.io both use "token", older registries
+// use "access_token"; a response may set either or both.
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+// fetchUpstreamToken implements challenge.TokenFetcher against a standard
+// Docker Registry Bearer token server: a GET to ch.Parameters["realm"] with
+// service/scope query parameters and, when configured, Basic credentials.
+func (p *proxyRegistry) fetchUpstreamToken(ctx context.Context, ch challenge.Challenge) (string, error) {
+	realm := ch.Parameters["realm"]
+	if realm == "" {
+		return "", errors.New("bearer challenge is missing a realm")
+	}
+
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse bearer token realm")
+	}
+
+	q := u.Query()
+	if service := ch.Parameters["service"]; service != "" {
+		q.Set("service", service)
+	}
+	if scope := ch.Parameters["scope"]; scope != "" {
+		q.Set("scope", scope)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to build bearer token request")
+	}
+	if p.cfg.Username != "" {
+		req.SetBasicAuth(p.cfg.Username, p.cfg.Password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to call bearer token realm")
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("bearer token realm %s responded with status %d", realm, resp.StatusCode)
+	}
+
+	var tr tokenResponse
+	if err = json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", errors.Wrap(err, "failed to decode bearer token response")
+	}
+
+	if tr.Token != "" {
+		return tr.Token, nil
+	}
+	if tr.AccessToken != "" {
+		return tr.AccessToken, nil
+	}
+	return "", errors.Errorf("bearer token realm %s returned no token", realm)
+}
+
+// recordDiscovered persists newly seen repositories in local storage so they
+// appear in future catalog listings without waiting on the next upstream fetch.
+func (p *proxyRegistry) recordDiscovered(ctx context.Context, repos registry.Repositories) {
+	p.mu.Lock()
+	for _, name := range repos.Repositories {
+		p.proxiedRepos[name] = true
+	}
+	p.mu.Unlock()
+
+	recorder, ok := p.storage.(repositoryRecorder)
+	if !ok {
+		return
+	}
+	for _, name := range repos.Repositories {
+		if err := recorder.CreateRepository(ctx, name); err != nil {
+			log.Printf("[WARN] failed to record proxied repository %q: %v", name, err)
+		}
+	}
+}
+
+func (p *proxyRegistry) mergeCatalog(local registry.Repositories) registry.Repositories {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.catalog == nil {
+		return local
+	}
+
+	seen := make(map[string]bool, len(local.Repositories))
+	merged := local
+	for _, name := range local.Repositories {
+		seen[name] = true
+	}
+	for _, name := range p.catalog.repos.Repositories {
+		if !seen[name] {
+			merged.Repositories = append(merged.Repositories, name)
+			seen[name] = true
+		}
+	}
+	return merged
+}
+
+func containsRepo(repos registry.Repositories, name string) bool {
+	for _, r := range repos.Repositories {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
@@ -22,6 +22,9 @@ import (
 	"github.com/go-pkgz/auth/token"
 	"github.com/gorilla/handlers"
 	"github.com/pkg/errors"
+	"github.com/zebox/registry-admin/app/audit"
+	"github.com/zebox/registry-admin/app/jobs"
+	"github.com/zebox/registry-admin/app/notify"
 	"github.com/zebox/registry-admin/app/registry"
 	"github.com/zebox/registry-admin/app/store"
 	"github.com/zebox/registry-admin/app/store/engine"
@@ -44,11 +47,20 @@ type Server struct {
 	RegistryService          registryInterface // main instance for connection to registry service
 	GarbageCollectorInterval int64
 	WebContentFS             *embed.FS
+	MetricsConfig            MetricsConfig
+	NotifyQueueSize          int // size of the bounded notification delivery queue shared by all endpoints, default used when zero
+	ProxyConfig              ProxyConfig
 
 	ctx         context.Context
 	httpsServer *http.Server
 	httpServer  *http.Server
 	lock        sync.Mutex
+	metrics     *metrics
+	health      *healthRegistry
+	notifier    *notify.Broadcaster
+	proxy       *proxyRegistry
+	auditLogger *audit.Logger
+	jobs        *jobs.Runner
 }
 
 // endpointsHandler contain main endpoints properties for used inside handlers
@@ -57,9 +69,54 @@ type endpointsHandler struct {
 	authenticator *auth.Service
 	l             log.L
 	ctx           context.Context // pass global context
+	notifier      *notify.Broadcaster
 }
 
-// registryInterface implement method for access data of a registry instance
+// publish fans event out to every configured notification endpoint. It is a
+// no-op when the server wasn't configured with a notifier, so handlers can
+// call it unconditionally.
+//
+// NOTE: only the bulk/manifest-index handlers in this package call publish so
+// far (registry.delete/registry.push on bulk-delete, retention and copy).
+// registryHandlers.events, registryHandlers.deleteDigest/syncRepositories and
+// userHandlers' create/update/delete/htpasswd paths this subsystem was also
+// scoped to cover live in handler files outside this tree snapshot, so they
+// still need a publish() call added once those files are available to edit.
+func (eh *endpointsHandler) publish(event notify.Event) {
+	if eh.notifier == nil {
+		return
+	}
+	eh.notifier.Publish(event)
+}
+
+// notifyActor builds a notify.Actor from the authenticated token claims
+// attached to r, mirroring auditActor so webhook payloads and audit records
+// agree on who performed an action.
+func notifyActor(r *http.Request) notify.Actor {
+	actor := notify.Actor{}
+
+	user, err := token.GetUserInfo(r)
+	if err != nil {
+		return actor
+	}
+
+	actor.Name = user.Name
+	actor.Role = user.Role()
+	if uid, ok := user.Attributes["uid"].(int64); ok {
+		actor.UID = uid
+	}
+	return actor
+}
+
+// registryInterface implement method for access data of a registry instance.
+//
+// NOTE: HtpasswdStatus was added to this contract by the metrics/health work
+// in this series; ManifestIndex, ManifestDigest and CopyManifest were added
+// by the multi-arch manifest and copy/retention work. In both cases the
+// concrete registry client satisfying registryInterface lives outside this
+// package (app/registry's production client, not the app/registry/challenge
+// helper package touched here) and needs the matching methods added there
+// before the server package builds against it.
 type registryInterface interface {
 
 	// Login is initials login step when docker login command call
@@ -69,6 +126,9 @@ type registryInterface interface {
 	Token(authRequest registry.TokenRequest) (string, error)
 
 	// ParseAuthenticateHeaderRequest will parse 'Www-Authenticate' header for extract token authorization data.
+	// Implementations are expected to delegate the raw parsing to the registry/challenge
+	// package, which understands the full RFC 7235 grammar (multiple challenges, Bearer and
+	// Basic schemes), and translate the resulting challenge.Challenge into a registry.TokenRequest.
 	ParseAuthenticateHeaderRequest(headerValue string) (authRequest registry.TokenRequest, err error)
 
 	// UpdateHtpasswd update user access list in .htpasswd file every time when users entries add/update/delete
@@ -78,6 +138,9 @@ type registryInterface interface {
 	// based on its response statuses.
 	APIVersionCheck(ctx context.Context) error
 
+	// HtpasswdStatus reports whether the htpasswd file backing basic auth is currently writable.
+	HtpasswdStatus() error
+
 	// Catalog return list a set of available repositories in the local registry cluster.
 	Catalog(ctx context.Context, n, last string) (registry.Repositories, error)
 
@@ -87,12 +150,25 @@ type registryInterface interface {
 	// Manifest will fetch the manifest identified by 'name' and 'reference' where 'reference' can be a tag or digest.
 	Manifest(ctx context.Context, repoName, tag string) (registry.ManifestSchemaV2, error)
 
+	// ManifestDigest resolves 'ref' (a tag or digest) to the manifest's own content digest -
+	// the value DeleteTag expects, since a manifest can only be deleted by digest, never by tag.
+	ManifestDigest(ctx context.Context, repoName, ref string) (digest string, err error)
+
+	// ManifestIndex fetches and parses the OCI image index / Docker manifest list identified by
+	// 'name' and 'reference', for multi-arch repositories that publish a manifest per platform
+	// rather than a single image manifest.
+	ManifestIndex(ctx context.Context, repoName, ref string) (registry.ManifestIndex, error)
+
 	// GetBlob retrieve information about image from config blob
 	GetBlob(ctx context.Context, name, digest string) (blob []byte, err error)
 
 	// DeleteTag will deleteDigest the manifest identified by name and reference. Note that a manifest can only be deleted
 	// by digest.
 	DeleteTag(ctx context.Context, repoName, digest string) error
+
+	// CopyManifest duplicates the manifest identified by digest from srcRepo into dstRepo, mounting its
+	// blobs rather than re-uploading them when the underlying registry supports cross-repository mounts.
+	CopyManifest(ctx context.Context, srcRepo, dstRepo, digest string) error
 }
 
 // responseMessage is the uniform response message pattern for various frontend framework like react-admin and other
@@ -116,6 +192,11 @@ func (s *Server) Run(ctx context.Context) error {
 		return errors.New("a registry service define required ")
 	}
 
+	if s.MetricsConfig.Enabled && s.MetricsConfig.ListenAddr != "" {
+		s.metrics = newMetrics()
+		go s.runMetricsServer()
+	}
+
 	switch s.SSLConfig.SSLMode {
 	case SSLNone:
 		log.Printf("[INFO] activate http rest server on %s:%d", s.Listen, s.Port)
@@ -204,10 +285,35 @@ func (s *Server) Shutdown() {
 func (s *Server) routes() chi.Router {
 	router := chi.NewRouter()
 
+	if s.metrics == nil {
+		s.metrics = newMetrics()
+	}
+
+	if s.ProxyConfig.RemoteURL != "" {
+		if s.proxy == nil {
+			s.proxy = newProxyRegistry(s.RegistryService, s.Storage, s.ProxyConfig, s.metrics)
+		}
+		s.RegistryService = s.proxy
+	}
+
+	// instrument every registry call regardless of proxy mode, so
+	// registry_calls_total/registry_call_duration_seconds/registry_call_errors_total
+	// fire on the primary catalog/manifest/delete path on a default install too.
+	if _, ok := s.RegistryService.(*instrumentedRegistry); !ok {
+		s.RegistryService = newInstrumentedRegistry(s.RegistryService, s.metrics)
+	}
+
+	s.health = newHealthRegistry(s.Storage, s.RegistryService)
+
 	router.Use(middleware.Throttle(1000), middleware.RealIP, R.Recoverer(log.Default()))
 	router.Use(middleware.Timeout(30 * time.Second))
 	router.Use(R.Ping)
 
+	if s.MetricsConfig.Enabled {
+		router.Use(s.metrics.middleware)
+	}
+	router.Get("/health", s.health.handler())
+
 	corsMiddleware := cors.New(cors.Options{
 		AllowedOrigins:   []string{s.Hostname, os.Getenv("RA_DEV_HOST")},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
@@ -222,18 +328,43 @@ func (s *Server) routes() chi.Router {
 	authHandler, _ := s.Authenticator.Handlers()
 	authMiddleware := s.Authenticator.Middleware()
 
+	// when no dedicated metrics listener is configured, expose /metrics on the main
+	// router but gate it behind admin RBAC so it isn't reachable anonymously.
+	if s.MetricsConfig.Enabled && s.MetricsConfig.ListenAddr == "" {
+		router.Group(func(r chi.Router) {
+			r.Use(authMiddleware.Auth, authMiddleware.RBAC("admin"), middleware.NoCache)
+			r.Get("/metrics", s.metrics.handler().ServeHTTP)
+		})
+	}
+
 	router.Group(func(r chi.Router) {
 		r.Use(middleware.Timeout(5 * time.Second))
 		r.Use(tollbooth_chi.LimitHandler(tollbooth.NewLimiter(10, nil)), middleware.NoCache)
 		r.Mount("/auth", authHandler)
 	})
 
+	if s.notifier == nil {
+		notifyStore, _ := s.Storage.(notify.Store) // nil when the storage engine doesn't persist notification endpoints
+		s.notifier = notify.NewBroadcaster(s.NotifyQueueSize, notifyStore, s.L)
+	}
+
+	if s.auditLogger == nil {
+		auditStore, _ := s.Storage.(audit.Store) // nil when the storage engine doesn't persist audit events
+		s.auditLogger = audit.NewLogger(auditStore, s.L)
+	}
+
+	if s.jobs == nil {
+		jobStore, _ := s.Storage.(jobs.Store) // nil when the storage engine doesn't persist job records
+		s.jobs = jobs.NewRunner(jobStore, s.L)
+	}
+
 	// initialing main endpoints properties for use in handlers
 	eh := endpointsHandler{
 		dataStore:     s.Storage,
 		authenticator: s.Authenticator,
 		l:             s.L,
 		ctx:           s.ctx,
+		notifier:      s.notifier,
 	}
 
 	// main endpoints routes
@@ -250,8 +381,10 @@ func (s *Server) routes() chi.Router {
 
 			// try to update users list in htpasswd from store if htpasswd is defined
 			if err := uh.registryService.UpdateHtpasswd(uh.userAdapter); err != nil {
+				s.metrics.htpasswdSyncTotal.WithLabelValues("error").Inc()
 				panic(fmt.Errorf("failed to update htpasswd: %v", err))
 			}
+			s.metrics.htpasswdSyncTotal.WithLabelValues("success").Inc()
 
 			// this route expose api for manipulation with User entries
 			rootRoute.Route("/users", func(routeUser chi.Router) {
@@ -264,6 +397,7 @@ func (s *Server) routes() chi.Router {
 				// operation create/update/deleteDigest with User items allow for admin only
 				routeUser.Group(func(routeAdminUser chi.Router) {
 					routeAdminUser.Use(authMiddleware.RBAC("admin"))
+					routeAdminUser.Use(auditMiddleware(s.auditLogger))
 
 					routeAdminUser.Post("/", uh.userCreateCtrl)
 					routeAdminUser.Put("/{id}", uh.userUpdateCtrl)
@@ -283,6 +417,7 @@ func (s *Server) routes() chi.Router {
 				// operation create/update/deleteDigest with Group items allow for admins only
 				routeGroup.Group(func(routeAdminGroup chi.Router) {
 					routeAdminGroup.Use(authMiddleware.RBAC("admin"))
+					routeAdminGroup.Use(auditMiddleware(s.auditLogger))
 
 					routeAdminGroup.Post("/", gh.groupCreateCtrl)
 					routeAdminGroup.Put("/{id}", gh.groupUpdateCtrl)
@@ -302,6 +437,7 @@ func (s *Server) routes() chi.Router {
 				// operation create/update/deleteDigest with Access items allow for admins only
 				routeAccess.Group(func(routeAdminAccess chi.Router) {
 					routeAdminAccess.Use(authMiddleware.RBAC("admin"))
+					routeAdminAccess.Use(auditMiddleware(s.auditLogger))
 					routeAdminAccess.Post("/", ah.accessAddCtrl)
 					routeAdminAccess.Put("/{id}", ah.accessUpdateCtrl)
 					routeAdminAccess.Delete("/{id}", ah.accessDeleteCtrl)
@@ -319,6 +455,12 @@ func (s *Server) routes() chi.Router {
 			}
 
 			// starting Data Service maintenance tasks such as garbage collector and repositories auto sync
+			//
+			// NOTE: metrics.gcRunsTotal/gcDuration/gcErrorsTotal are registered but not
+			// yet incremented - doing so requires RepositoriesMaintenance itself to
+			// report per-run outcomes, since that's where each GC sweep actually
+			// happens; wiring it through is follow-up work against
+			// service.DataService rather than this file.
 			rh.dataService.RepositoriesMaintenance(s.ctx, s.GarbageCollectorInterval)
 
 			// route API for manipulations registry entries (catalog/tags/manifest/deleteDigest)
@@ -342,14 +484,66 @@ func (s *Server) routes() chi.Router {
 					routeApiManagerRegistry.Use(authMiddleware.Auth, middleware.NoCache)
 					routeApiManagerRegistry.Use(authMiddleware.RBAC("admin", "manager"))
 					routeApiManagerRegistry.Get("/catalog/blobs", rh.imageConfig)
+
+					// platform matrix for multi-arch (OCI image index / Docker manifest list) repositories
+					mih := manifestIndexHandlers{endpointsHandler: eh, registryService: s.RegistryService}
+					routeApiManagerRegistry.Get("/catalog/index", mih.manifestIndexCtrl)
 				})
 
 				routeRegistry.Group(func(routeApiAdminRegistry chi.Router) {
 					routeApiAdminRegistry.Use(authMiddleware.RBAC("admin"))
+					routeApiAdminRegistry.Use(auditMiddleware(s.auditLogger))
 					routeApiAdminRegistry.Get("/sync", rh.syncRepositories)
 					routeApiAdminRegistry.Delete("/catalog/*", rh.deleteDigest)
+
+					// cascade delete for manifest indexes: ?cascade=true also removes every
+					// platform-specific manifest the index refers to, admin-only. This is a
+					// separate route rather than a flag on rh.deleteDigest because cascade
+					// delete first has to resolve the index into its platform manifests
+					// (via registryService.ManifestIndex) before any deletion happens, and
+					// deleteDigest's single-digest signature has no way to express that.
+					routeApiAdminRegistry.Delete("/catalog/index", mih.cascadeDeleteIndexCtrl)
+
+					// bulk lifecycle operations - delete, retention sweeps, cross-repo copy - run as
+					// background jobs tracked through bh.jobs, admin only
+					bh := bulkHandlers{endpointsHandler: eh, registryService: s.RegistryService, jobs: s.jobs}
+					routeApiAdminRegistry.Post("/catalog/bulk-delete", bh.bulkDeleteCtrl)
+					routeApiAdminRegistry.Post("/retention", bh.retentionCtrl)
+					routeApiAdminRegistry.Post("/copy", bh.copyCtrl)
+					routeApiAdminRegistry.Get("/jobs/{id}", bh.jobStatusCtrl)
+					routeApiAdminRegistry.Delete("/jobs/{id}", bh.jobCancelCtrl)
+
+					// cache invalidation and TTL overrides for pull-through proxy mode, admin only
+					if s.proxy != nil {
+						ph := proxyHandlers{endpointsHandler: eh, proxy: s.proxy}
+						routeApiAdminRegistry.Route("/proxy", func(routeProxyRegistry chi.Router) {
+							routeProxyRegistry.Post("/invalidate", ph.proxyInvalidateCtrl)
+							routeProxyRegistry.Put("/ttl", ph.proxyTTLCtrl)
+						})
+					}
 				})
 			})
+
+			// this route expose api for managing webhook notification endpoints, admin only
+			nh := notificationsHandlers{endpointsHandler: eh, broadcaster: s.notifier}
+			rootRoute.Route("/notifications/endpoints", func(routeNotifications chi.Router) {
+				routeNotifications.Use(authMiddleware.Auth, authMiddleware.RBAC("admin"), middleware.NoCache)
+				routeNotifications.Use(auditMiddleware(s.auditLogger))
+
+				routeNotifications.Get("/", nh.notificationEndpointsListCtrl)
+				routeNotifications.Post("/", nh.notificationEndpointCreateCtrl)
+				routeNotifications.Put("/{id}", nh.notificationEndpointUpdateCtrl)
+				routeNotifications.Delete("/{id}", nh.notificationEndpointDeleteCtrl)
+			})
+
+			// this route exposes the audit trail of administrative actions, admin only
+			auh := auditHandlers{endpointsHandler: eh, logger: s.auditLogger}
+			rootRoute.Route("/audit", func(routeAudit chi.Router) {
+				routeAudit.Use(authMiddleware.Auth, authMiddleware.RBAC("admin"), middleware.NoCache)
+
+				routeAudit.Get("/", auh.auditListCtrl)
+				routeAudit.Get("/export", auh.auditExportCtrl)
+			})
 		})
 	})
 
@@ -359,6 +553,17 @@ func (s *Server) routes() chi.Router {
 	return router
 }
 
+// runMetricsServer serves /metrics on its own listener, typically bound to
+// localhost so the endpoint never needs to cross the public RBAC boundary.
+func (s *Server) runMetricsServer() {
+	log.Printf("[INFO] activate metrics server on %s", s.MetricsConfig.ListenAddr)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics.handler())
+	if err := s.makeHTTPServer(s.MetricsConfig.ListenAddr, mux).ListenAndServe(); err != nil {
+		log.Printf("[WARN] metrics server terminated, %v", err)
+	}
+}
+
 // accessLogHandler the handler will log all request for access to the server
 func accessLogHandler(wr io.Writer) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {